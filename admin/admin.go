@@ -5,10 +5,13 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/nilangshah/hrapp/util"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"net"
 	"net/http"
+	"sync"
 )
 
 //Admin server struct
@@ -19,6 +22,16 @@ type AdminServer struct {
 	Health            bool
 	markedForShutdown bool
 	config            *AdminConfig
+	checksMu          sync.Mutex
+	checks            []*check
+	routes            []Route
+}
+
+//Route is an endpoint of the service served by the admin server next to /metrics and the probes
+type Route struct {
+	Method  string
+	Path    string
+	Handler http.Handler
 }
 
 //Admin server configuration
@@ -26,9 +39,17 @@ type AdminConfig struct {
 	ListenAddress string `config:"listen-address"`
 }
 
-//Create instance of admin server
-func NewServer(config *AdminConfig) *AdminServer {
-	return &AdminServer{config: config, Health: true, adminStoppedEvent: make(chan error, 1)}
+//Validate checks that the listen address has a port
+func (c *AdminConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		return errors.Wrapf(err, "listen-address %q", c.ListenAddress)
+	}
+	return nil
+}
+
+//Create instance of admin server serving routes besides its own endpoints
+func NewServer(config *AdminConfig, routes ...Route) *AdminServer {
+	return &AdminServer{config: config, Health: false, adminStoppedEvent: make(chan error, 1), routes: routes}
 }
 
 //Interface method to initialize admin server
@@ -42,6 +63,12 @@ func (s *AdminServer) Init(logger *zap.Logger) error {
 	prometheus.DefaultRegisterer = prometheus.WrapRegistererWith(prometheus.Labels{"servicename": util.SERVICENAME, "serviceversion": util.SERVICEVERSION}, prometheus.DefaultRegisterer)
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	router.GET("/health", s.health)
+	for _, probe := range []string{LIVEZ, READYZ, STARTUPZ} {
+		router.GET("/"+probe, gin.WrapH(s.probeHandler(probe)))
+	}
+	for _, r := range s.routes {
+		router.Handle(r.Method, r.Path, gin.WrapH(r.Handler))
+	}
 
 	s.adminHTTPServer = &http.Server{
 		Addr:    s.config.ListenAddress,
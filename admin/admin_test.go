@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+func probe(t *testing.T, s *AdminServer, name string) (int, *probeResult) {
+	rec := httptest.NewRecorder()
+	s.probeHandler(name).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+name, nil))
+	result := &probeResult{}
+	assert.Equal(t, nil, json.Unmarshal(rec.Body.Bytes(), result))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	return rec.Code, result
+}
+
+func TestProbes(t *testing.T) {
+	s := NewServer(&AdminConfig{ListenAddress: "127.0.0.1:0"})
+	s.Logger = zap.NewNop()
+	var storeErr error
+	s.AddCheck("listener", func(ctx context.Context) error { return nil }, LIVEZ, READYZ, STARTUPZ)
+	s.AddCheck("store", func(ctx context.Context) error { return storeErr }, READYZ)
+
+	//probes without failing checks pass, each lists its own checks
+	code, result := probe(t, s, READYZ)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", result.Status)
+	assert.Equal(t, 2, len(result.Checks))
+	assert.Equal(t, "store", result.Checks[1].Name)
+	code, result = probe(t, s, LIVEZ)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, len(result.Checks))
+
+	storeErr = errors.New("cassandra is down")
+	code, result = probe(t, s, READYZ)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "failed", result.Status)
+	assert.Equal(t, &checkResult{Name: "store", Status: "failed", Error: "cassandra is down", LatencyMs: result.Checks[1].LatencyMs}, result.Checks[1])
+	assert.Equal(t, "ok", result.Checks[0].Status)
+	code, _ = probe(t, s, STARTUPZ)
+	assert.Equal(t, http.StatusOK, code)
+
+	//checks still running when the request is done fail
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	hang := make(chan struct{})
+	defer close(hang)
+	s.AddCheck("hang", func(ctx context.Context) error { <-hang; return nil }, LIVEZ)
+	res := s.runChecks(ctx, LIVEZ)
+	assert.Equal(t, "failed", res.Status)
+	assert.Equal(t, "hang", res.Checks[1].Name)
+	assert.T(t, res.Checks[1].LatencyMs >= 10, res.Checks[1].LatencyMs)
+}
+
+func TestRoutes(t *testing.T) {
+	verify := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) })
+	s := NewServer(&AdminConfig{ListenAddress: "127.0.0.1:0"}, Route{Method: http.MethodGet, Path: "/verify", Handler: verify})
+	assert.Equal(t, nil, s.Init(zap.NewNop()))
+	serve := func(method string, path string) int {
+		rec := httptest.NewRecorder()
+		s.adminHTTPServer.Handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+
+	//routes are served with the method they were given only
+	assert.Equal(t, http.StatusTeapot, serve(http.MethodGet, "/verify"))
+	assert.NotEqual(t, http.StatusTeapot, serve(http.MethodPost, "/verify"))
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/livez"))
+
+	//unhealthy until the skeleton sees the gRPC listener bound
+	assert.Equal(t, http.StatusServiceUnavailable, serve(http.MethodGet, "/health"))
+	s.Health = true
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/health"))
+}
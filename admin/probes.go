@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+//Probes served by the admin server as /livez, /readyz and /startupz
+const (
+	LIVEZ    = "livez"
+	READYZ   = "readyz"
+	STARTUPZ = "startupz"
+	//Longest time a probe waits for its checks, checks still running are reported as failed
+	PROBETIMEOUT = 5 * time.Second
+)
+
+//CheckFunc returns an error when the dependency it checks doesn't work
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name   string
+	fn     CheckFunc
+	probes map[string]bool
+}
+
+//probeResult is the JSON body of a probe endpoint
+type probeResult struct {
+	Status string         `json:"status"`
+	Checks []*checkResult `json:"checks"`
+}
+
+type checkResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+//AddCheck adds a named check to the given probes, checks can be added while the admin server runs
+func (s *AdminServer) AddCheck(name string, fn CheckFunc, probes ...string) {
+	c := &check{name: name, fn: fn, probes: map[string]bool{}}
+	for _, probe := range probes {
+		c.probes[probe] = true
+	}
+	s.checksMu.Lock()
+	defer s.checksMu.Unlock()
+	s.checks = append(s.checks, c)
+}
+
+//probeHandler runs the checks of probe concurrently and answers 200 when they all pass, 503 otherwise
+func (s *AdminServer) probeHandler(probe string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result := s.runChecks(r.Context(), probe)
+		w.Header().Set("Content-Type", "application/json")
+		if result.Status != "ok" {
+			s.Logger.Warn("Health: Probe failed", zap.String("probe", probe), zap.Any("checks", result.Checks))
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+func (s *AdminServer) runChecks(ctx context.Context, probe string) *probeResult {
+	s.checksMu.Lock()
+	var checks []*check
+	for _, c := range s.checks {
+		if c.probes[probe] {
+			checks = append(checks, c)
+		}
+	}
+	s.checksMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, PROBETIMEOUT)
+	defer cancel()
+	result := &probeResult{Status: "ok", Checks: make([]*checkResult, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			result.Checks[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+	for _, c := range result.Checks {
+		if c.Status != "ok" {
+			result.Status = "failed"
+		}
+	}
+	return result
+}
+
+//runCheck runs c until it returns or ctx is done
+func runCheck(ctx context.Context, c *check) *checkResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- c.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "check did not finish")
+	}
+	res := &checkResult{Name: c.name, Status: "ok", LatencyMs: float64(time.Since(start)) / float64(time.Millisecond)}
+	if err != nil {
+		res.Status, res.Error = "failed", err.Error()
+	}
+	return res
+}
@@ -0,0 +1,247 @@
+package hrapp
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	cacheReqs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "How many employee cache lookups, partitioned by hit/miss",
+		},
+		[]string{"result"},
+	)
+	cacheEvictions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cache_evictions_total",
+			Help: "How many employees dropped from the cache, partitioned by reason (capacity, expired, invalidated)",
+		},
+		[]string{"reason"},
+	)
+	//registerCacheMetrics registers the cache metrics with the first cache created
+	registerCacheMetrics sync.Once
+)
+
+//cachestore is a read-through LRU cache in front of another EmployeeStore. GetEmployee is served
+//from the cache for ttl, concurrent misses for the same id share one backend call, and writes
+//through the cache invalidate the employees they change
+type cachestore struct {
+	EmployeeStore
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[int64]*list.Element
+	lru     *list.List
+	//gen changes on every invalidation so that loads racing a write don't cache what they read before it
+	gen    uint64
+	flight singleflight.Group
+	now    func() time.Time
+	logger *zap.Logger
+}
+
+type cacheEntry struct {
+	emp     *Employee
+	expires time.Time
+}
+
+//NewCacheStore wraps store with a cache of at most size employees kept for ttl
+func NewCacheStore(logger *zap.Logger, store EmployeeStore, size int, ttl time.Duration) EmployeeStore {
+	logger.Info("DataAccess: Caching employee store", zap.Int("size", size), zap.Duration("ttl", ttl))
+	return &cachestore{
+		EmployeeStore: store,
+		size:          size,
+		ttl:           ttl,
+		entries:       make(map[int64]*list.Element, size),
+		lru:           list.New(),
+		now:           time.Now,
+		logger:        logger,
+	}
+}
+
+func (c *cachestore) GetEmployee(id *EmployeeId) (*Employee, error) {
+	if emp, ok := c.lookup(id.Id); ok {
+		cacheReqs.WithLabelValues("hit").Inc()
+		return emp, nil
+	}
+	cacheReqs.WithLabelValues("miss").Inc()
+	c.mu.Lock()
+	gen := c.gen
+	c.mu.Unlock()
+	v, err, _ := c.flight.Do(strconv.FormatInt(id.Id, 10), func() (interface{}, error) {
+		emp, err := c.EmployeeStore.GetEmployee(id)
+		if err != nil {
+			return nil, err
+		}
+		c.add(emp, gen)
+		return emp, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return copyEmployee(v.(*Employee)), nil
+}
+
+//GetEmployees serves cached employees and fetches the rest with one backend call
+func (c *cachestore) GetEmployees(ids []int64) ([]*Employee, []int64, error) {
+	ids = uniqueIds(ids)
+	found := make(map[int64]*Employee, len(ids))
+	misses := []int64{}
+	for _, id := range ids {
+		if emp, ok := c.lookup(id); ok {
+			found[id] = emp
+		} else {
+			misses = append(misses, id)
+		}
+	}
+	cacheReqs.WithLabelValues("hit").Add(float64(len(found)))
+	if len(misses) > 0 {
+		cacheReqs.WithLabelValues("miss").Add(float64(len(misses)))
+		c.mu.Lock()
+		gen := c.gen
+		c.mu.Unlock()
+		fetched, _, err := c.EmployeeStore.GetEmployees(misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, emp := range fetched {
+			c.add(emp, gen)
+			found[emp.Id] = emp
+		}
+	}
+	employees, missing := orderEmployees(ids, found)
+	return employees, missing, nil
+}
+
+func (c *cachestore) CreateEmployee(emp *Employee, managerId int64) error {
+	defer c.invalidate(emp.Id, managerId)
+	return c.EmployeeStore.CreateEmployee(emp, managerId)
+}
+
+func (c *cachestore) UpdateEmployee(emp *Employee) error {
+	defer c.invalidate(emp.Id)
+	return c.EmployeeStore.UpdateEmployee(emp)
+}
+
+func (c *cachestore) DeleteEmployee(id *EmployeeId) (*Employee, error) {
+	emp, err := c.EmployeeStore.DeleteEmployee(id)
+	if emp != nil {
+		c.invalidate(id.Id, emp.ManagerId)
+	} else {
+		c.invalidate(id.Id)
+	}
+	return emp, err
+}
+
+func (c *cachestore) PutEmployees(employees []*Employee) error {
+	ids := make([]int64, len(employees))
+	for i, emp := range employees {
+		ids[i] = emp.Id
+	}
+	defer c.invalidate(ids...)
+	return c.EmployeeStore.PutEmployees(employees)
+}
+
+//MoveEmployee empties the cache, a move changes the employee, both managers and possibly all of its reports
+func (c *cachestore) MoveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error) {
+	defer c.invalidateAll()
+	return c.EmployeeStore.MoveEmployee(id, newManagerId, moveReports)
+}
+
+//healthCheck checks the wrapped store
+func (c *cachestore) healthCheck() error {
+	if h, ok := c.EmployeeStore.(healthChecker); ok {
+		return h.healthCheck()
+	}
+	return nil
+}
+
+//prepareReload checks config with the wrapped store, the returned function applies the new cache TTL to
+//the employees cached from then on and applies config to the wrapped store
+func (c *cachestore) prepareReload(config *ServiceImplConfig) (func(), error) {
+	applyStore := func() {}
+	if r, ok := c.EmployeeStore.(reloader); ok {
+		var err error
+		if applyStore, err = r.prepareReload(config); err != nil {
+			return nil, err
+		}
+	}
+	return func() {
+		c.mu.Lock()
+		if c.ttl != config.CacheTTL {
+			c.logger.Info("DataAccess: Cache TTL changed", zap.Duration("old", c.ttl), zap.Duration("new", config.CacheTTL))
+			c.ttl = config.CacheTTL
+		}
+		c.mu.Unlock()
+		applyStore()
+	}, nil
+}
+
+//lookup returns a copy of the cached employee unless it is missing or expired
+func (c *cachestore) lookup(id int64) (*Employee, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem, "expired")
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return copyEmployee(entry.emp), true
+}
+
+//add caches emp unless the cache was invalidated since gen was read
+func (c *cachestore) add(emp *Employee, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	entry := &cacheEntry{emp: copyEmployee(emp), expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[emp.Id]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[emp.Id] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back(), "capacity")
+	}
+}
+
+func (c *cachestore) invalidate(ids ...int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, id := range ids {
+		if elem, ok := c.entries[id]; ok {
+			c.remove(elem, "invalidated")
+		}
+	}
+}
+
+func (c *cachestore) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Back(), "invalidated")
+	}
+}
+
+func (c *cachestore) remove(elem *list.Element, reason string) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).emp.Id)
+	cacheEvictions.WithLabelValues(reason).Inc()
+}
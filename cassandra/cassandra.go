@@ -1,6 +1,7 @@
 package cassandra
 
 import (
+	"context"
 	"github.com/gocql/gocql"
 	"github.com/pkg/errors"
 	"time"
@@ -8,7 +9,9 @@ import (
 
 type SessionInterface interface {
 	Query(string, ...interface{}) QueryInterface
+	Batch(gocql.BatchType) BatchInterface
 	SetPageSize(int)
+	SetConsistency(gocql.Consistency)
 	Close()
 	Health() bool
 }
@@ -18,11 +21,14 @@ type QueryInterface interface {
 	Exec() error
 	Iter() IterInterface
 	Scan(...interface{}) error
+	PageSize(int) QueryInterface
+	PageState([]byte) QueryInterface
 }
 
 type IterInterface interface {
 	Scan(...interface{}) bool
 	Close() error
+	PageState() []byte
 }
 
 type BatchInterface interface {
@@ -85,9 +91,12 @@ func (s *Session) Query(stmt string, values ...interface{}) QueryInterface {
 	return NewQuery(s.session.Query(stmt, values...))
 }
 
-// Health to get session health. Can be
+// Health checks that the session is open and that cassandra answers a query
 func (s *Session) Health() bool {
-	return true
+	if s.session.Closed() {
+		return false
+	}
+	return s.session.Query("SELECT now() FROM system.local").Exec() == nil
 }
 
 // Query wraps the session's executebatch method
@@ -110,6 +119,11 @@ func (s *Session) SetPageSize(n int) {
 	s.session.SetPageSize(n)
 }
 
+// SetConsistency wraps the session's SetConsistency method, it applies to the queries created afterwards
+func (s *Session) SetConsistency(c gocql.Consistency) {
+	s.session.SetConsistency(c)
+}
+
 // Close wraps the session's close method
 func (s *Session) Close() {
 	s.session.Close()
@@ -135,6 +149,16 @@ func (q *Query) Scan(dest ...interface{}) error {
 	return q.query.Scan(dest...)
 }
 
+// PageSize wraps the query's PageSize method, it overrides the session page size for this query
+func (q *Query) PageSize(n int) QueryInterface {
+	return NewQuery(q.query.PageSize(n))
+}
+
+// PageState wraps the query's PageState method, the query returns a single page starting at state
+func (q *Query) PageState(state []byte) QueryInterface {
+	return NewQuery(q.query.PageState(state))
+}
+
 // Scan is a wrapper for the iter's Scan method
 func (i *Iter) Scan(dest ...interface{}) bool {
 	return i.iter.Scan(dest...)
@@ -146,6 +170,11 @@ func (i *Iter) Close() error {
 	return i.iter.Close()
 }
 
+// PageState wraps the iter's PageState method, empty when there are no more pages
+func (i *Iter) PageState() []byte {
+	return i.iter.PageState()
+}
+
 //Cassandra configuration to connect cassandra
 type CassandraConfig struct {
 	ClusterHosts string `config:"cluster_hosts"`
@@ -153,11 +182,27 @@ type CassandraConfig struct {
 	Consistency  string `config:"consistency"`
 }
 
-//Creates cassandra session based on given cassandraconfig
-func CreateSession(conf *CassandraConfig) (SessionInterface, error) {
-	var c gocql.Consistency
+//Validate checks that hosts and keyspace are set and that the consistency is known to gocql
+func (conf *CassandraConfig) Validate() error {
+	if conf.ClusterHosts == "" || conf.Keyspace == "" {
+		return errors.New("cluster_hosts and keyspace are required")
+	}
+	if _, err := conf.ParseConsistency(); err != nil {
+		return errors.Wrapf(err, "consistency %q", conf.Consistency)
+	}
+	return nil
+}
 
+//ParseConsistency returns the gocql consistency named by the config
+func (conf *CassandraConfig) ParseConsistency() (gocql.Consistency, error) {
+	var c gocql.Consistency
 	err := c.UnmarshalText([]byte(conf.Consistency))
+	return c, err
+}
+
+//Creates cassandra session based on given cassandraconfig
+func CreateSession(conf *CassandraConfig) (SessionInterface, error) {
+	c, err := conf.ParseConsistency()
 	if err != nil {
 		return nil, errors.Wrap(err, "Unknown Cassandra consistency config")
 	}
@@ -172,3 +217,35 @@ func CreateSession(conf *CassandraConfig) (SessionInterface, error) {
 
 	return NewSession(s), nil
 }
+
+//IsTimeout reports whether err means cassandra didn't answer within the timeout
+func IsTimeout(err error) bool {
+	switch errors.Cause(err).(type) {
+	case *gocql.RequestErrReadTimeout, *gocql.RequestErrWriteTimeout:
+		return true
+	}
+	switch errors.Cause(err) {
+	case gocql.ErrTimeoutNoResponse, gocql.ErrTooManyTimeouts, context.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+//IsUnavailable reports whether err means cassandra couldn't be reached or lacks replicas to serve the request
+func IsUnavailable(err error) bool {
+	if _, ok := errors.Cause(err).(*gocql.RequestErrUnavailable); ok {
+		return true
+	}
+	switch errors.Cause(err) {
+	case gocql.ErrNoConnections, gocql.ErrSessionClosed, gocql.ErrConnectionClosed, gocql.ErrNoStreams, gocql.ErrUnavailable:
+		return true
+	}
+	return false
+}
+
+//IsProtocolError reports whether cassandra rejected the request as malformed, as it does for a corrupt paging state
+func IsProtocolError(err error) bool {
+	reqErr, ok := errors.Cause(err).(gocql.RequestError)
+	//0x000A is PROTOCOL_ERROR, gocql doesn't export its error codes
+	return ok && reqErr.Code() == 0x000A
+}
@@ -2,19 +2,17 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
 	h "github.com/nilangshah/hrapp"
+	"github.com/nilangshah/hrapp/grpcserver"
+	"github.com/nilangshah/hrapp/hrappclient"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/balancer/roundrobin"
-	"google.golang.org/grpc/credentials"
-	"io/ioutil"
 	"os"
-	"sync"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -25,17 +23,18 @@ var tlsEnabled = flag.Bool("tls-enabled", true, "Connect hrapp service over tls"
 var certPath = flag.String("certpath", "client/certs/127.0.0.1.crt", "Run gRPC service over tls")
 var keyPath = flag.String("keypath", "client/certs/127.0.0.1.key", "Run gRPC service over tls")
 var caPath = flag.String("capath", "client/certs/root-ca.crt", "Run gRPC service over tls")
+var walk = flag.Bool("walk", false, "Crawl the hierarchy with GetEmployees calls for the reports of every manager instead of one GetReportingTree call")
+var depth = flag.Int("depth", 0, "Levels of reports below empid to print, 0 for all levels")
+var includeManagers = flag.Bool("include-managers", false, "Also print the managers of empid up to the root, each with empid's branch only")
+var concurrency = flag.Int("concurrency", hrappclient.DEFAULTCONCURRENCY, "GetEmployees calls in flight at once with -walk")
+var format = flag.String("format", "json", "Output format: "+strings.Join(h.ExportFormats, ", ")+". Formats other than json are rendered by the ExportOrg RPC unless -walk or -include-managers is set")
+var dialTimeout = flag.Duration("dial-timeout", 5*time.Second, "How long to wait for the connection to the service")
+var callTimeout = flag.Duration("call-timeout", 10*time.Second, "How long every call to the service may take, 0 for no limit")
+var retries = flag.Int("retries", 2, "How many times a read failing with UNAVAILABLE or running out of call-timeout is retried")
+var retryBackoff = flag.Duration("retry-backoff", hrappclient.DEFAULTRETRYBACKOFF, "Wait before the first retry, doubled for every following one")
+var hedgeDelay = flag.Duration("hedge-delay", 0, "Send a second request for reads not answered within this delay, 0 disables hedging")
+var timeout = flag.Duration("timeout", 0, "How long fetching the whole reporting structure may take, 0 for no limit")
 
-var wait sync.WaitGroup
-
-type EmpHierarchy struct {
-	Id      int64           `json:"id"`
-	Name    string          `json:"name"`
-	Title   string          `json:"title"`
-	Reports []*EmpHierarchy `json:"reports"`
-}
-
-var result sync.Map
 var logger *zap.Logger
 var err error
 
@@ -43,28 +42,105 @@ func main() {
 	flag.Parse()
 
 	logger, err = zap.NewProduction()
-	logger.Info("Flags", zap.String("connect-addr", *svcAddr), zap.Int64("empid", *empId), zap.Bool("pretty", *pretty), zap.Bool("tls-enabled", *tlsEnabled), zap.Strings("cert,key,ca", []string{*certPath, *keyPath, *caPath}))
 	if err != nil {
 		fmt.Printf("Error occured while creating logger")
 		os.Exit(1)
 	}
+	logger.Info("Flags", zap.String("connect-addr", *svcAddr), zap.Int64("empid", *empId), zap.Bool("pretty", *pretty), zap.Bool("tls-enabled", *tlsEnabled), zap.Strings("cert,key,ca", []string{*certPath, *keyPath, *caPath}), zap.Int("depth", *depth), zap.Bool("include-managers", *includeManagers), zap.Int("concurrency", *concurrency))
+	os.Exit(run())
+}
 
-	startTime := time.Now()
+//run prints the reporting structure and returns the exit code
+func run() int {
+	if !h.ValidExportFormat(*format) {
+		fmt.Printf("Unknown format %q, expected one of %s\n", *format, strings.Join(h.ExportFormats, ", "))
+		return 2
+	}
+	if *depth < 0 || *concurrency < 1 {
+		fmt.Println("depth must not be negative and concurrency must be at least 1")
+		return 2
+	}
+
+	//Ctrl-C cancels the calls in flight, what was fetched until then is printed
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Warn("Signal received, cancelling outstanding calls", zap.String("signal", sig.String()))
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	clientConn := creategRPCClient(svcAddr)
-	defer clientConn.Close()
-	hrappClient := h.NewHrappClient(clientConn)
-	wait.Add(1)
+	startTime := time.Now()
 
-	getEmployee(1, hrappClient)
+	client, err := hrappclient.New(logger, &hrappclient.Config{
+		Address:      *svcAddr,
+		TlsConfig:    &grpcserver.TlsConfig{TlsEnabled: *tlsEnabled, CAPath: *caPath, CertPath: *certPath, KeyPath: *keyPath},
+		DialTimeout:  *dialTimeout,
+		CallTimeout:  *callTimeout,
+		Retries:      *retries,
+		RetryBackoff: *retryBackoff,
+		HedgeDelay:   *hedgeDelay,
+	})
+	if err != nil {
+		fmt.Println(err.Error())
+		logger.Error("gRPCClient: error occured whilecreating hrApp client", zap.Error(err))
+		return 1
+	}
+	defer client.Close()
+	defer func() {
+		stats := client.Stats()
+		logger.Info("gRPCClient: Calls retried and hedged", zap.Int64("retries", stats.Retries), zap.Int64("hedges", stats.Hedges), zap.Int64("hedgeWins", stats.HedgeWins))
+	}()
+
+	if *format != "json" && !*walk && !*includeManagers {
+		if err = client.ExportOrg(ctx, *empId, *depth, *format, os.Stdout); err != nil {
+			fmt.Println(err.Error())
+			logger.Error("Error occured while gRPC service call", zap.Error(err))
+			return 1
+		}
+		logger.Info("Time taken to export employee data", zap.Duration("latency", time.Since(startTime)))
+		return 0
+	}
 
-	wait.Wait()
+	ans, err := client.FetchHierarchy(ctx, *empId, &hrappclient.FetchOptions{
+		MaxDepth:        *depth,
+		IncludeManagers: *includeManagers,
+		Walk:            *walk,
+		Concurrency:     *concurrency,
+	})
+	exitCode := 0
+	if crawlErr, ok := err.(*hrappclient.CrawlError); ok && ans != nil {
+		//print the partial hierarchy, but still fail
+		fmt.Fprintln(os.Stderr, crawlErr.Error())
+		logger.Error("Reporting structure is incomplete", zap.Error(crawlErr))
+		exitCode = 1
+	} else if err != nil {
+		fmt.Println(err.Error())
+		logger.Error("Error occured while gRPC service call", zap.Error(err))
+		return 1
+	}
 
 	logger.Info("Time taken to fetch employee data", zap.Duration("latency", time.Since(startTime)))
 
-	ans := buildReporting(*empId)
+	if *format != "json" {
+		if err = h.WriteOrg(os.Stdout, *format, ans.ReportingTree(), 0); err != nil {
+			fmt.Println(err)
+			return 1
+		}
+		return exitCode
+	}
+
 	var b []byte
-	var err error
 	if *pretty {
 		b, err = json.MarshalIndent(ans, "", "    ")
 
@@ -73,80 +149,8 @@ func main() {
 	}
 	if err != nil {
 		fmt.Println(err)
-		return
+		return 1
 	}
 	fmt.Println(string(b))
-}
-
-//build reporting hierarchy
-func buildReporting(i int64) *EmpHierarchy {
-	ans := &EmpHierarchy{}
-	res, found := result.Load(i)
-	if found {
-		ans.Id = i
-		ans.Name = res.(*h.Employee).Name
-		ans.Title = res.(*h.Employee).Title
-		ans.Reports = make([]*EmpHierarchy,len(res.(*h.Employee).Reports))
-		for j, report := range res.(*h.Employee).Reports {
-			ans.Reports[j]= buildReporting(report)
-		}
-	}
-	return ans
-}
-
-//Create gRPC client connection to gRPC service
-func creategRPCClient(addr *string) *grpc.ClientConn {
-	//init certs
-	if *tlsEnabled {
-		certificate, err := tls.LoadX509KeyPair(
-			*certPath,
-			*keyPath,
-		)
-		certPool := x509.NewCertPool()
-		bs, err := ioutil.ReadFile(*caPath)
-		if err != nil {
-			logger.Error("failed to read ca cert: %s", zap.Error(err))
-		}
-		ok := certPool.AppendCertsFromPEM(bs)
-		if !ok {
-			logger.Error("failed to append certs")
-		}
-		transportCreds := credentials.NewTLS(&tls.Config{
-			Certificates: []tls.Certificate{certificate},
-			RootCAs:      certPool,
-		})
-
-		clientConnection, err := grpc.Dial(*addr, grpc.WithTransportCredentials(transportCreds), grpc.WithBalancerName(roundrobin.Name))
-		if err != nil {
-			logger.Error("gRPCClient: error occured whilecreating hrApp client", zap.Error(err))
-		}
-		return clientConnection
-	} else {
-		clientConnection, err := grpc.Dial(*addr, grpc.WithInsecure(), grpc.WithBalancerName(roundrobin.Name))
-		if err != nil {
-			logger.Error("gRPCClient: error occured whilecreating hrApp client", zap.Error(err))
-		}
-		return clientConnection
-	}
-
-}
-
-//Fetch employee details for given employeeId by calling gRPC server
-func getEmployee(empId int64, client h.HrappClient) {
-	defer wait.Done()
-	response, err := client.GetEmployee(context.Background(), &h.EmployeeId{Id: empId})
-	if err != nil {
-		fmt.Println(err.Error())
-		logger.Error("Error occured while gRPC service call", zap.Error(err))
-		os.Exit(1)
-	}
-	result.Store(empId, response)
-	wait.Add(len(response.Reports))
-	for _, emp := range response.Reports {
-		go getEmployee(emp, client)
-	}
-
-	if err != nil {
-		logger.Error("Error occured while fetching employee data", zap.Error(err))
-	}
+	return exitCode
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/nilangshah/hrapp"
+	"github.com/nilangshah/hrapp/hrappclient"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//importEmployees imports the CSV or EmpHierarchy JSON file named by the first argument, - for stdin, and
+//prints the changes. The rows are streamed to the ImportEmployees RPC of the running service, only -dry-run
+//and -offline read the configured store directly. It returns the exit code
+func importEmployees(conf *hrappConfig, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "Input format, csv or json, guessed from the file extension when empty")
+	dryRun := flags.Bool("dry-run", false, "Validate and print the changes against the configured store without writing them")
+	offline := flags.Bool("offline", false, "Write the configured store directly, only while no service uses it")
+	connectAddr := flags.String("connect-addr", conf.GRPCConfig.ListenAddress, "Address of the hrapp service the rows are sent to")
+	dialTimeout := flags.Duration("dial-timeout", 5*time.Second, "How long to wait for the connection to the service")
+	flags.Parse(args)
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Println("Error occured while creating logger")
+		return 1
+	}
+	defer logger.Sync()
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: hrapp [flags] import [-format csv|json] [-dry-run|-offline] [-connect-addr host:port] <file|->")
+		return 2
+	}
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			logger.Error("Import: Failed to open input", zap.Error(err))
+			return 1
+		}
+		defer file.Close()
+		input = file
+	}
+	var employees []*hrapp.Employee
+	switch *format {
+	case "csv":
+		employees, err = hrapp.ParseEmployeesCSV(input)
+	case "json":
+		employees, err = hrapp.ParseHierarchyJSON(input)
+	default:
+		logger.Error("Import: Unknown input format, use -format csv or json", zap.String("format", *format))
+		return 2
+	}
+	if err != nil {
+		logger.Error("Import: Failed to parse input", zap.Error(err))
+		return 1
+	}
+
+	var plan *hrapp.ImportPlan
+	if *dryRun || *offline {
+		plan, err = importToStore(logger, conf.Service, employees, *dryRun)
+		if errors.Cause(err) == hrapp.ErrInvalidImport {
+			logger.Error("Import: Import is invalid, nothing was written", zap.Error(err))
+			return 1
+		}
+	} else {
+		plan, err = importToService(logger, conf, *connectAddr, *dialTimeout, employees)
+		if status.Code(err) == codes.InvalidArgument {
+			logger.Error("Import: Import is invalid, nothing was written", zap.Error(err))
+			return 1
+		}
+	}
+	if err != nil {
+		//rows written before a failed batch are kept, the error tells how many
+		logger.Error("Import: Import failed", zap.Error(err))
+		return 1
+	}
+	if err := plan.WriteDiff(os.Stdout); err != nil {
+		logger.Error("Import: Failed to print changes", zap.Error(err))
+		return 1
+	}
+	return 0
+}
+
+//importToService streams the employees to the service listening at address
+func importToService(logger *zap.Logger, conf *hrappConfig, address string, dialTimeout time.Duration, employees []*hrapp.Employee) (*hrapp.ImportPlan, error) {
+	client, err := hrappclient.New(logger, &hrappclient.Config{Address: address, TlsConfig: conf.GRPCConfig.TlsConfig, DialTimeout: dialTimeout})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	resp, err := client.Import(context.Background(), employees, false)
+	if err != nil {
+		return nil, err
+	}
+	return &hrapp.ImportPlan{Creates: resp.Created, Updates: resp.Updated}, nil
+}
+
+//importToStore imports into the store itself, the caches of a running service are refreshed by their ttl
+func importToStore(logger *zap.Logger, config *hrapp.ServiceImplConfig, employees []*hrapp.Employee, dryRun bool) (*hrapp.ImportPlan, error) {
+	storeConfig := *config
+	storeConfig.CacheSize = 0
+	store, err := hrapp.NewEmployeeStore(logger, &storeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open employee store")
+	}
+	defer store.Close()
+	return hrapp.ImportOrg(store, employees, dryRun)
+}
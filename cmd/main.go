@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"github.com/nilangshah/hrapp"
 	"github.com/nilangshah/hrapp/admin"
 	"github.com/nilangshah/hrapp/cassandra"
+	"github.com/nilangshah/hrapp/config"
 	"github.com/nilangshah/hrapp/grpcserver"
 	"github.com/nilangshah/hrapp/skeleton"
+	"github.com/nilangshah/hrapp/util"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 )
 
 var svcAddr = flag.String("svc-address", "mydomain.com:8086", "The address to listen on for gRPC requests.")
@@ -16,16 +24,108 @@ var certpath = flag.String("certpath", "grpcserver/certs/mydomain.com.crt", "Run
 var keypath = flag.String("keypath", "grpcserver/certs/mydomain.com.key", "Run gRPC service over tls")
 var capath = flag.String("capath", "grpcserver/certs/root-ca.crt", "Run gRPC service over tls")
 var cassandraAddr = flag.String("cassandra-addr", "127.0.0.1:9042", "Cassandra connect address")
+var store = flag.String("store", "cassandra", "Employee store backend, one of "+strings.Join(hrapp.StoreNames(), "|"))
+var seedFile = flag.String("seed-file", "", "JSON file of employees to load into the memory store, or into an empty file store")
+var dataDir = flag.String("data-dir", "data", "Directory of the file store snapshot and log")
+var snapshotEvery = flag.Int("snapshot-every", hrapp.SNAPSHOTEVERY, "Number of file store writes between snapshots")
+var cacheSize = flag.Int("cache-size", 0, "Number of employees cached in front of the store, 0 disables the cache")
+var cacheTTL = flag.Duration("cache-ttl", 30*time.Second, "How long a cached employee is served before it is read again")
+var searchRefresh = flag.Duration("search-refresh", hrapp.SEARCHREFRESH, "How often the cassandra store rebuilds its search index")
+var logLevel = flag.String("log-level", "info", "Lowest level logged, debug, info, warn or error")
+var configFile = flag.String("config", "", "YAML or JSON config file, its settings are overridden by "+strings.ToUpper(util.SERVICENAME)+"_* environment variables and by flags")
+
+//hrappConfig is the configuration read from the config file, the environment and the flags
+type hrappConfig struct {
+	skeleton.ServerConfig
+	Service *hrapp.ServiceImplConfig `config:"service"`
+}
+
+//flagKeys maps flags to the config keys they override
+var flagKeys = map[string]string{
+	"svc-address":    "grpc.listen-address",
+	"admin-address":  "admin.listen-address",
+	"tls-enabled":    "grpc.tls.enabled",
+	"certpath":       "grpc.tls.cert-path",
+	"keypath":        "grpc.tls.key-path",
+	"capath":         "grpc.tls.ca-path",
+	"cassandra-addr": "service.cassandra.cluster_hosts",
+	"store":          "service.store",
+	"seed-file":      "service.seed-file",
+	"data-dir":       "service.data-dir",
+	"snapshot-every": "service.snapshot-every",
+	"cache-size":     "service.cache-size",
+	"cache-ttl":      "service.cache-ttl",
+	"search-refresh": "service.search-refresh",
+	"log-level":      "log-level",
+}
 
 func main() {
 	flag.Parse()
 
-	serviceImplConfig := &hrapp.ServiceImplConfig{DBConfig: &cassandra.CassandraConfig{*cassandraAddr, "hrapp", "ONE"}}
+	conf, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Configuration error:", err)
+		os.Exit(2)
+	}
+	serviceImplConfig := conf.Service
+	switch flag.Arg(0) {
+	case "verify":
+		os.Exit(verify(serviceImplConfig, flag.Args()[1:]))
+	case "import":
+		os.Exit(importEmployees(conf, flag.Args()[1:]))
+	}
 	serviceImpl := hrapp.NewServiceImpl(serviceImplConfig)
-	serviceConfig := &grpcserver.GRPCConfig{ListenAddress: *svcAddr, TlsConfig: &grpcserver.TlsConfig{*tlsEnabled, *capath, *certpath, *keypath}}
-
-	adminConfig := &admin.AdminConfig{ListenAddress: *adminAddr}
+	skeleton.Init(serviceImpl, &conf.ServerConfig, admin.Route{Method: http.MethodGet, Path: "/verify", Handler: http.HandlerFunc(serviceImpl.ServeVerify)})
+	//SIGHUP reads the configuration again, the skeleton applies the settings of the service with the server
+	//settings once they all passed their checks
+	skeleton.OnReload(func() (*skeleton.ServerConfig, func(), error) {
+		conf, err := loadConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		apply, err := serviceImpl.PrepareReload(conf.Service)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &conf.ServerConfig, apply, nil
+	})
+	skeleton.AddCheck("store", func(ctx context.Context) error {
+		return serviceImpl.HealthCheck()
+	}, admin.READYZ)
+	if err := skeleton.Run(); err != nil {
+		os.Exit(1)
+	}
+}
 
-	skeleton.Init(serviceImpl, &skeleton.ServerConfig{GRPCConfig: serviceConfig, AdminConfig: adminConfig})
-	skeleton.Run()
+//loadConfig starts from the flag defaults, then applies the config file, the environment and the flags set
+//on the command line, and validates the result
+func loadConfig() (*hrappConfig, error) {
+	conf := &hrappConfig{
+		ServerConfig: skeleton.ServerConfig{
+			GRPCConfig:  &grpcserver.GRPCConfig{ListenAddress: *svcAddr, TlsConfig: &grpcserver.TlsConfig{TlsEnabled: *tlsEnabled, CAPath: *capath, CertPath: *certpath, KeyPath: *keypath}},
+			AdminConfig: &admin.AdminConfig{ListenAddress: *adminAddr},
+			LogLevel:    *logLevel,
+		},
+		Service: &hrapp.ServiceImplConfig{
+			Store:         *store,
+			DBConfig:      &cassandra.CassandraConfig{ClusterHosts: *cassandraAddr, Keyspace: "hrapp", Consistency: "ONE"},
+			SeedFile:      *seedFile,
+			DataDir:       *dataDir,
+			SnapshotEvery: *snapshotEvery,
+			CacheSize:     *cacheSize,
+			CacheTTL:      *cacheTTL,
+			SearchRefresh: *searchRefresh,
+		},
+	}
+	overrides := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			overrides[key] = f.Value.String()
+		}
+	})
+	loader := &config.Loader{File: *configFile, EnvPrefix: util.SERVICENAME}
+	if err := loader.Load(conf, overrides); err != nil {
+		return nil, err
+	}
+	return conf, nil
 }
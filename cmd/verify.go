@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nilangshah/hrapp"
+	"go.uber.org/zap"
+)
+
+//verify checks the hierarchy kept in the configured store and prints the report as JSON, -repair fixes
+//what has an obvious fix. It returns the exit code, 1 while problems remain
+func verify(config *hrapp.ServiceImplConfig, args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "Repair dangling reports, employees with several managers, wrong manager ids and orphans with a known manager")
+	adoptOrphansUnder := flags.Int64("adopt-orphans-under", 0, "With -repair, manager of the orphans whose manager id names no employee")
+	flags.Parse(args)
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Println("Error occured while creating logger")
+		return 1
+	}
+	defer logger.Sync()
+
+	//the memory and file stores belong to the running server, writing them from here would be lost or
+	//corrupt its log, only cassandra is shared
+	if *repair && config.Store != "" && config.Store != "cassandra" {
+		logger.Error("Verify: -repair needs the cassandra store", zap.String("store", config.Store))
+		return 1
+	}
+
+	//read the store itself, a cache could hide what needs repair
+	storeConfig := *config
+	storeConfig.CacheSize = 0
+	store, err := hrapp.NewEmployeeStore(logger, &storeConfig)
+	if err != nil {
+		logger.Error("Verify: Failed to open employee store", zap.Error(err))
+		return 1
+	}
+	defer store.Close()
+
+	var opts *hrapp.RepairOptions
+	if *repair {
+		opts = &hrapp.RepairOptions{AdoptOrphansUnder: *adoptOrphansUnder}
+	}
+	report, err := hrapp.VerifyOrg(store, opts)
+	if err != nil {
+		logger.Error("Verify: Failed to verify hierarchy", zap.Error(err))
+		return 1
+	}
+	b, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		logger.Error("Verify: Failed to encode report", zap.Error(err))
+		return 1
+	}
+	fmt.Println(string(b))
+	if report.Healthy {
+		return 0
+	}
+	if len(report.Repaired) == 0 {
+		return 1
+	}
+	after, err := hrapp.VerifyOrg(store, nil)
+	if err != nil {
+		logger.Error("Verify: Failed to verify repaired hierarchy", zap.Error(err))
+		return 1
+	}
+	if !after.Healthy {
+		logger.Warn("Verify: Problems remain after repair", zap.Int64s("roots", after.Roots), zap.Int("cycles", len(after.Cycles)))
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//Validator is implemented by config structs checking their values once they are loaded
+type Validator interface {
+	Validate() error
+}
+
+//Loader fills structs from the config tags of their fields. Nested structs are reached through tagged
+//struct pointer fields, keys are the tags joined with dots like grpc.tls.enabled, and embedded structs
+//without a tag add their fields to the enclosing struct. Values are read from File, then from the
+//environment, then from the overrides passed to Load, each replacing the ones before
+type Loader struct {
+	//File is a YAML or JSON file of nested objects, empty to read none
+	File string
+	//EnvPrefix starts the environment variable of every key, the key is upper cased with dots and dashes
+	//turned to underscores, HRAPP and grpc.tls.cert-path read HRAPP_GRPC_TLS_CERT_PATH
+	EnvPrefix string
+	//LookupEnv reads the environment, os.LookupEnv when nil
+	LookupEnv func(key string) (string, bool)
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+//Load sets the fields of target, a pointer to a struct, and validates every struct implementing Validator.
+//Fields not found in any source keep their value
+func (l *Loader) Load(target interface{}, overrides map[string]string) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("config target must be a pointer to a struct, got %T", target)
+	}
+	fields := map[string]reflect.Value{}
+	collect(v.Elem(), "", fields)
+
+	if l.File != "" {
+		values, err := readFile(l.File)
+		if err != nil {
+			return err
+		}
+		if err := set(fields, values, "config file "+l.File); err != nil {
+			return err
+		}
+	}
+	lookup := l.LookupEnv
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	for _, key := range sortedKeys(fields) {
+		name := EnvName(l.EnvPrefix, key)
+		if value, ok := lookup(name); ok {
+			if err := setField(key, fields[key], value); err != nil {
+				return errors.Wrapf(err, "environment variable %s", name)
+			}
+		}
+	}
+	if err := set(fields, overrides, "flags"); err != nil {
+		return err
+	}
+	return validate(v, "")
+}
+
+//EnvName returns the environment variable read for key
+func EnvName(prefix string, key string) string {
+	name := strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+//collect adds the leaves of v to fields, allocating nil struct pointers on the way
+func collect(v reflect.Value, prefix string, fields map[string]reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("config")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		fv := v.Field(i)
+		if tag == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				collect(fv, prefix, fields)
+			}
+			continue
+		}
+		key := prefix + tag
+		switch {
+		case f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct:
+			if fv.IsNil() {
+				fv.Set(reflect.New(f.Type.Elem()))
+			}
+			collect(fv.Elem(), key+".", fields)
+		case f.Type.Kind() == reflect.Struct:
+			collect(fv, key+".", fields)
+		default:
+			fields[key] = fv
+		}
+	}
+}
+
+//readFile flattens the nested objects of a YAML or JSON file to dotted keys
+func readFile(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse config file %s", path)
+	}
+	values := map[string]string{}
+	if err := flatten(doc, "", values); err != nil {
+		return nil, errors.Wrapf(err, "config file %s", path)
+	}
+	return values, nil
+}
+
+func flatten(doc map[string]interface{}, prefix string, values map[string]string) error {
+	for key, value := range doc {
+		switch v := value.(type) {
+		case nil:
+		case map[interface{}]interface{}:
+			nested := make(map[string]interface{}, len(v))
+			for k, nv := range v {
+				nested[fmt.Sprint(k)] = nv
+			}
+			if err := flatten(nested, prefix+key+".", values); err != nil {
+				return err
+			}
+		case map[string]interface{}:
+			if err := flatten(v, prefix+key+".", values); err != nil {
+				return err
+			}
+		case []interface{}:
+			return errors.Errorf("%s%s: lists are not supported", prefix, key)
+		default:
+			values[prefix+key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+//set applies values to fields, keys without a field are an error
+func set(fields map[string]reflect.Value, values map[string]string, source string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fv, ok := fields[key]
+		if !ok {
+			return errors.Errorf("%s: unknown config key %q, known keys are %s", source, key, strings.Join(sortedKeys(fields), ", "))
+		}
+		if err := setField(key, fv, values[key]); err != nil {
+			return errors.Wrap(err, source)
+		}
+	}
+	return nil
+}
+
+//setField parses value into fv according to its type
+func setField(key string, fv reflect.Value, value string) error {
+	invalid := func(err error) error {
+		return errors.Errorf("invalid value %q for %s: %v", value, key, err)
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return invalid(err)
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return invalid(err)
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return invalid(err)
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return invalid(err)
+		}
+		fv.SetFloat(f)
+	default:
+		return errors.Errorf("unsupported type %s of %s", fv.Type(), key)
+	}
+	return nil
+}
+
+//validate calls Validate on v and on the structs below it, nested structs first
+func validate(v reflect.Value, key string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("config")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) || (tag == "" && !f.Anonymous) {
+			continue
+		}
+		nested := key
+		if tag != "" {
+			nested = strings.TrimPrefix(key+"."+tag, ".")
+		}
+		if err := validate(v.Field(i), nested); err != nil {
+			return err
+		}
+	}
+	//structs embedded without being exported are only walked through, their own Validate can't be reached
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				if key == "" {
+					return errors.Wrap(err, "invalid config")
+				}
+				return errors.Wrapf(err, "invalid config %s", key)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(fields map[string]reflect.Value) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/pkg/errors"
+)
+
+type testTls struct {
+	Enabled  bool   `config:"enabled"`
+	CertPath string `config:"cert-path"`
+}
+
+func (c *testTls) Validate() error {
+	if c.Enabled && c.CertPath == "" {
+		return errors.New("cert-path is required when tls is enabled")
+	}
+	return nil
+}
+
+type testServer struct {
+	ListenAddress string   `config:"listen-address"`
+	Tls           *testTls `config:"tls"`
+}
+
+type testEmbedded struct {
+	Server *testServer `config:"server"`
+}
+
+type testConfig struct {
+	testEmbedded
+	CacheSize int           `config:"cache-size"`
+	CacheTTL  time.Duration `config:"cache-ttl"`
+	Ignored   string
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	assert.Equal(t, nil, ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	yamlFile := writeFile(t, dir, "hrapp.yaml", "server:\n  listen-address: :8086\n  tls:\n    enabled: true\n    cert-path: file.crt\ncache-size: 10\ncache-ttl: 1m\n")
+	env := map[string]string{"HRAPP_SERVER_TLS_CERT_PATH": "env.crt", "HRAPP_CACHE_SIZE": "20"}
+	loader := &Loader{File: yamlFile, EnvPrefix: "hrapp", LookupEnv: func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}}
+
+	//flags win over the environment, which wins over the file, unset keys keep their value
+	conf := &testConfig{CacheTTL: time.Second, Ignored: "kept"}
+	err = loader.Load(conf, map[string]string{"cache-size": "30"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ":8086", conf.Server.ListenAddress)
+	assert.Equal(t, &testTls{Enabled: true, CertPath: "env.crt"}, conf.Server.Tls)
+	assert.Equal(t, 30, conf.CacheSize)
+	assert.Equal(t, time.Minute, conf.CacheTTL)
+	assert.Equal(t, "kept", conf.Ignored)
+
+	//JSON files are read the same way
+	loader.File = writeFile(t, dir, "hrapp.json", `{"server": {"tls": {"enabled": false}}, "cache-ttl": "5s"}`)
+	conf = &testConfig{}
+	assert.Equal(t, nil, loader.Load(conf, nil))
+	assert.Equal(t, 5*time.Second, conf.CacheTTL)
+	assert.Equal(t, 20, conf.CacheSize)
+
+	errs := map[string]string{
+		"server:\n  listen-adress: :8086\n":    "unknown config key \"server.listen-adress\"",
+		"cache-size: ten\n":                    "invalid value \"ten\" for cache-size",
+		"server:\n  tls:\n    enabled: true\n": "invalid config server.tls: cert-path is required when tls is enabled",
+	}
+	loader.LookupEnv = func(string) (string, bool) { return "", false }
+	for content, expected := range errs {
+		loader.File = writeFile(t, dir, "bad.yaml", content)
+		err := loader.Load(&testConfig{}, nil)
+		assert.NotEqual(t, nil, err)
+		assert.T(t, strings.Contains(err.Error(), expected), err.Error())
+	}
+
+	loader.File = ""
+	err = loader.Load(&testConfig{}, map[string]string{"cache-ttl": "soon"})
+	assert.T(t, strings.Contains(err.Error(), "flags: invalid value \"soon\" for cache-ttl"), err.Error())
+
+	assert.Equal(t, "HRAPP_SERVER_TLS_CERT_PATH", EnvName("hrapp", "server.tls.cert-path"))
+}
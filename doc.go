@@ -1,3 +1,4 @@
 package hrapp // import "github.com/nilangshah/hrapp"
 //go:generate protoc --go_out=plugins=grpc:. hrapp.proto
 // mockgen -source=cassandra/cassandra.go -package=mock -destination=mock/mock_cassandra.go
+// mockgen -source=employeestore.go -package=hrapp -self_package=github.com/nilangshah/hrapp -destination=mock_employeestore_test.go
@@ -1,6 +1,10 @@
 package hrapp
 
 import (
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
 	c "github.com/nilangshah/hrapp/cassandra"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -8,48 +12,121 @@ import (
 )
 
 var (
-	reqCount   *prometheus.CounterVec
-	reqLatency *prometheus.SummaryVec
+	reqCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_requests_total",
+			Help: "How many db requests processed, partitioned by method and result (success, notfound, conflict, unavailable, timeout, invalid, failure)",
+		},
+		[]string{"result", "method"},
+	)
+	reqLatency = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name: "db_requests_latency",
+			Help: "Time taken to complete dbquery",
+		},
+		[]string{"method"},
+	)
+)
+
+var (
+	ErrEmployeeNotFound   = errors.New("employee not found")
+	ErrEmployeeExists     = errors.New("employee already exists")
+	ErrEmployeeHasReports = errors.New("employee has reports")
+	ErrStoreUnavailable   = errors.New("employee store unavailable")
+	ErrStoreTimeout       = errors.New("employee store timeout")
+	ErrInvalidPageToken   = errors.New("invalid page token")
+	ErrHierarchyCycle     = errors.New("reporting hierarchy cycle")
+	ErrInvalidImport      = errors.New("invalid import")
+	ErrInvalidMove        = errors.New("invalid move")
 )
 
 const (
-	GETEMPLOYEE = "SELECT id,name,title,reports FROM hrapp.employee where id=?;"
+	GETEMPLOYEE    = "SELECT id,name,title,reports,manager_id FROM hrapp.employee where id=?;"
+	GETEMPLOYEES   = "SELECT id,name,title,reports,manager_id FROM hrapp.employee where id IN ?;"
+	LISTEMPLOYEES  = "SELECT id,name,title,reports,manager_id FROM hrapp.employee;"
+	INSERTEMPLOYEE = "INSERT INTO hrapp.employee (id,name,title,reports,manager_id) VALUES (?,?,?,?,?);"
+	UPDATEEMPLOYEE = "UPDATE hrapp.employee SET name=?,title=? WHERE id=?;"
+	DELETEEMPLOYEE = "DELETE FROM hrapp.employee WHERE id=?;"
+	ADDREPORT      = "UPDATE hrapp.employee SET reports=reports+? WHERE id=?;"
+	REMOVEREPORT   = "UPDATE hrapp.employee SET reports=reports-? WHERE id=?;"
+	SETMANAGER     = "UPDATE hrapp.employee SET manager_id=? WHERE id=?;"
+	//Most ids bound to a single IN query, larger batches are split
+	MAXINQUERY = 100
+	//Most rows written by a single logged batch of PutEmployees
+	MAXPUTBATCH = 50
 )
 
 //EmployeeDB interface to access employee details
 type EmployeeStore interface {
 	GetEmployee(*EmployeeId) (*Employee, error)
+	//GetEmployees returns the employees found in the order of ids without duplicates, and the ids not found
+	GetEmployees(ids []int64) ([]*Employee, []int64, error)
+	//CreateEmployee stores a new employee and adds it to the reports of managerId, 0 means no manager
+	CreateEmployee(emp *Employee, managerId int64) error
+	//UpdateEmployee changes name and title of an existing employee, reports are left untouched
+	UpdateEmployee(*Employee) error
+	//DeleteEmployee removes an employee without reports and returns the deleted record
+	DeleteEmployee(*EmployeeId) (*Employee, error)
+	//MoveEmployee makes newManagerId, 0 for none, the manager of id and returns the moved employee. Its reports
+	//move along when moveReports is set, otherwise they are handed to its old manager
+	MoveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error)
+	//PutEmployees writes whole rows as given, creating or overwriting them without checking the hierarchy.
+	//It is meant for repairs and bulk loads that keep the hierarchy consistent themselves
+	PutEmployees([]*Employee) error
+	//GetManagerChain returns the managers of an employee ordered from direct manager up to the root
+	GetManagerChain(*EmployeeId) ([]*Employee, error)
+	//ListEmployees returns up to pageSize employees matching filter from the page at pageState, empty for
+	//the first page, and the state of the next page which is empty after the last one
+	ListEmployees(filter *EmployeeFilter, pageSize int, pageState []byte) ([]*Employee, []byte, error)
+	//SearchEmployees returns a page of the employees matching query, ranked exact name matches first, then
+	//name prefix and then substring matches. Page states work as in ListEmployees
+	SearchEmployees(query *SearchQuery, pageSize int, pageState []byte) ([]*Employee, []byte, error)
 	Close()
 }
 
+//EmployeeFilter selects the employees returned by ListEmployees, empty fields match everyone
+type EmployeeFilter struct {
+	//Title matches employees with this title, case insensitive
+	Title string
+	//NamePrefix matches employees whose name starts with it, case insensitive
+	NamePrefix string
+}
+
+//Match reports whether emp passes every set field of the filter
+func (f *EmployeeFilter) Match(emp *Employee) bool {
+	if f.Title != "" && !strings.EqualFold(emp.Title, f.Title) {
+		return false
+	}
+	return f.NamePrefix == "" || (len(emp.Name) >= len(f.NamePrefix) && strings.EqualFold(emp.Name[:len(f.NamePrefix)], f.NamePrefix))
+}
+
 type employeestore struct {
 	dbSession c.SessionInterface
 	logger    *zap.Logger
+	//search indexes the employees read by the last rebuild and the writes made through this store since,
+	//it is rebuilt in the background every searchRefresh until stopRefresh is closed
+	search         *searchIndex
+	searchRefresh  time.Duration
+	stopRefresh    chan struct{}
+	refreshStopped chan struct{}
 }
 
-//DBInit create database session
-func EmployeeStoreInit(logger *zap.Logger, config *c.CassandraConfig) (EmployeeStore, error) {
+//DBInit create database session, searchRefresh is how often the search index is rebuilt, SEARCHREFRESH when 0
+func EmployeeStoreInit(logger *zap.Logger, config *c.CassandraConfig, searchRefresh time.Duration) (EmployeeStore, error) {
 	logger.Info("DataAccess: Initializing database session")
-	impl := &employeestore{logger: logger}
+	if searchRefresh <= 0 {
+		searchRefresh = SEARCHREFRESH
+	}
+	impl := &employeestore{logger: logger, search: newSearchIndex(), searchRefresh: searchRefresh}
 	cassandra, _ := c.CreateSession(config)
-	reqCount = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "db_requests_total",
-			Help: "How many db requests processed, partitioned by method and sucess/failure",
-		},
-		[]string{"result", "method"},
-	)
-	reqLatency = prometheus.NewSummaryVec(
-		prometheus.SummaryOpts{
-			Name: "db_requests_latency",
-			Help: "Time taken to complete dbquery",
-		},
-		[]string{"method"},
-	)
 	prometheus.MustRegister(reqCount, reqLatency)
 	if cassandra !=nil && cassandra.Health() {
 		impl.dbSession = cassandra
 		logger.Info("DataAccess: Database session initialized successfully")
+		if err := impl.rebuildSearchIndex(); err != nil {
+			logger.Error("EmployeeDB: Failed to build search index, retrying in the background", zap.Error(err))
+		}
+		impl.startSearchRefresh()
 		return impl, nil
 	} else {
 		logger.Error("DataAccess: Database session healthcheck failed")
@@ -57,21 +134,549 @@ func EmployeeStoreInit(logger *zap.Logger, config *c.CassandraConfig) (EmployeeS
 	}
 }
 
+//healthCheck fails when cassandra doesn't answer
+func (e *employeestore) healthCheck() error {
+	if e.dbSession == nil || !e.dbSession.Health() {
+		return errors.New("DataAccess: Database session healthcheck failed")
+	}
+	return nil
+}
+
+//prepareReload parses the configured consistency, the returned function switches the session to it,
+//queries already running keep theirs
+func (e *employeestore) prepareReload(config *ServiceImplConfig) (func(), error) {
+	consistency, err := config.DBConfig.ParseConsistency()
+	if err != nil {
+		return nil, errors.Wrap(err, "Unknown Cassandra consistency config")
+	}
+	return func() {
+		e.dbSession.SetConsistency(consistency)
+		e.logger.Info("DataAccess: Database consistency set", zap.Stringer("consistency", consistency))
+	}, nil
+}
+
 //Fetch employee details from database given employeeId
 func (e *employeestore) GetEmployee(id *EmployeeId) (*Employee, error) {
 	timer := prometheus.NewTimer(reqLatency.WithLabelValues("getemployee"))
 	defer timer.ObserveDuration()
 	e.logger.Debug("EmployeeDB: Fetching employee details", zap.Int64("empId", id.Id))
-	iter := e.dbSession.Query(GETEMPLOYEE).Bind(id.Id).Iter()
-	emp := &Employee{}
-	iter.Scan(&emp.Id, &emp.Name, &emp.Title, &emp.Reports)
-	reqCount.WithLabelValues("success", "getemployee").Inc()
+	emp, found, err := e.fetch(id.Id)
+	if err == nil && !found {
+		err = errors.Wrapf(ErrEmployeeNotFound, "employee %d", id.Id)
+	}
+	reqCount.WithLabelValues(resultLabel(err), "getemployee").Inc()
+	if err != nil {
+		e.logger.Debug("EmployeeDB: Failed fetching employee details", zap.Int64("empId", id.Id), zap.Error(err))
+		return nil, err
+	}
 	e.logger.Debug("EmployeeDB: Success fetching employee details", zap.Int64("empId", id.Id))
 	return emp, nil
 }
 
+//Fetch several employees with IN queries of at most MAXINQUERY ids
+func (e *employeestore) GetEmployees(ids []int64) ([]*Employee, []int64, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("getemployees"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Fetching employees", zap.Int("count", len(ids)))
+	ids = uniqueIds(ids)
+	found := make(map[int64]*Employee, len(ids))
+	err := e.fetchAll(ids, found)
+	reqCount.WithLabelValues(resultLabel(err), "getemployees").Inc()
+	if err != nil {
+		return nil, nil, err
+	}
+	employees, missing := orderEmployees(ids, found)
+	e.logger.Debug("EmployeeDB: Success fetching employees", zap.Int("found", len(employees)), zap.Int("missing", len(missing)))
+	return employees, missing, nil
+}
+
+//Store a new employee and link it to its manager in one logged batch
+func (e *employeestore) CreateEmployee(emp *Employee, managerId int64) error {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("createemployee"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Creating employee", zap.Int64("empId", emp.Id), zap.Int64("managerId", managerId))
+	err := e.createEmployee(emp, managerId)
+	reqCount.WithLabelValues(resultLabel(err), "createemployee").Inc()
+	if err != nil {
+		return err
+	}
+	e.search.put(emp)
+	e.logger.Debug("EmployeeDB: Success creating employee", zap.Int64("empId", emp.Id))
+	return nil
+}
+
+func (e *employeestore) createEmployee(emp *Employee, managerId int64) error {
+	if managerId != 0 {
+		if _, found, err := e.fetch(managerId); err != nil {
+			return err
+		} else if !found {
+			return errors.Wrapf(ErrEmployeeNotFound, "manager %d", managerId)
+		}
+	}
+	if _, found, err := e.fetch(emp.Id); err != nil {
+		return err
+	} else if found {
+		return errors.Wrapf(ErrEmployeeExists, "employee %d", emp.Id)
+	}
+	batch := e.dbSession.Batch(gocql.LoggedBatch)
+	batch.Query(INSERTEMPLOYEE, emp.Id, emp.Name, emp.Title, []int64{}, managerId)
+	if managerId != 0 {
+		batch.Query(ADDREPORT, []int64{emp.Id}, managerId)
+	}
+	if err := batch.ExecuteBatch(); err != nil {
+		return dbError(err, "insert employee")
+	}
+	return nil
+}
+
+//Update name and title of an existing employee
+func (e *employeestore) UpdateEmployee(emp *Employee) error {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("updateemployee"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Updating employee", zap.Int64("empId", emp.Id))
+	err := e.updateEmployee(emp)
+	reqCount.WithLabelValues(resultLabel(err), "updateemployee").Inc()
+	if err != nil {
+		return err
+	}
+	e.search.put(emp)
+	e.logger.Debug("EmployeeDB: Success updating employee", zap.Int64("empId", emp.Id))
+	return nil
+}
+
+func (e *employeestore) updateEmployee(emp *Employee) error {
+	if _, found, err := e.fetch(emp.Id); err != nil {
+		return err
+	} else if !found {
+		return errors.Wrapf(ErrEmployeeNotFound, "employee %d", emp.Id)
+	}
+	if err := e.dbSession.Query(UPDATEEMPLOYEE).Bind(emp.Name, emp.Title, emp.Id).Exec(); err != nil {
+		return dbError(err, "update employee")
+	}
+	return nil
+}
+
+//Delete an employee and unlink it from its manager in one logged batch
+func (e *employeestore) DeleteEmployee(id *EmployeeId) (*Employee, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("deleteemployee"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Deleting employee", zap.Int64("empId", id.Id))
+	emp, err := e.deleteEmployee(id.Id)
+	reqCount.WithLabelValues(resultLabel(err), "deleteemployee").Inc()
+	if err != nil {
+		return nil, err
+	}
+	e.search.delete(id.Id)
+	e.logger.Debug("EmployeeDB: Success deleting employee", zap.Int64("empId", id.Id))
+	return emp, nil
+}
+
+func (e *employeestore) deleteEmployee(id int64) (*Employee, error) {
+	emp, found, err := e.fetch(id)
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, errors.Wrapf(ErrEmployeeNotFound, "employee %d", id)
+	}
+	if len(emp.Reports) > 0 {
+		return nil, errors.Wrapf(ErrEmployeeHasReports, "employee %d", id)
+	}
+	batch := e.dbSession.Batch(gocql.LoggedBatch)
+	if emp.ManagerId != 0 {
+		batch.Query(REMOVEREPORT, []int64{id}, emp.ManagerId)
+	}
+	batch.Query(DELETEEMPLOYEE, id)
+	if err := batch.ExecuteBatch(); err != nil {
+		return nil, dbError(err, "delete employee")
+	}
+	return emp, nil
+}
+
+//fetchAll reads the rows of ids into found, with one IN query per MAXINQUERY ids
+func (e *employeestore) fetchAll(ids []int64, found map[int64]*Employee) error {
+	for start := 0; start < len(ids); start += MAXINQUERY {
+		end := start + MAXINQUERY
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := e.fetchIn(ids[start:end], found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *employeestore) fetchIn(ids []int64, found map[int64]*Employee) error {
+	iter := e.dbSession.Query(GETEMPLOYEES).Bind(ids).Iter()
+	emp := &Employee{}
+	for iter.Scan(&emp.Id, &emp.Name, &emp.Title, &emp.Reports, &emp.ManagerId) {
+		found[emp.Id] = emp
+		emp = &Employee{}
+	}
+	if err := iter.Close(); err != nil {
+		return dbError(err, "fetch employees")
+	}
+	return nil
+}
+
+//fetch reads a single employee row, found is false when the row does not exist
+func (e *employeestore) fetch(id int64) (*Employee, bool, error) {
+	iter := e.dbSession.Query(GETEMPLOYEE).Bind(id).Iter()
+	emp := &Employee{}
+	found := iter.Scan(&emp.Id, &emp.Name, &emp.Title, &emp.Reports, &emp.ManagerId)
+	if err := iter.Close(); err != nil {
+		return nil, false, dbError(err, "fetch employee")
+	}
+	return emp, found, nil
+}
+
+//Move an employee to a new manager, all rows change in one logged batch
+func (e *employeestore) MoveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("moveemployee"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Moving employee", zap.Int64("empId", id), zap.Int64("newManagerId", newManagerId), zap.Bool("moveReports", moveReports))
+	emp, err := e.moveEmployee(id, newManagerId, moveReports)
+	reqCount.WithLabelValues(resultLabel(err), "moveemployee").Inc()
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Debug("EmployeeDB: Success moving employee", zap.Int64("empId", id), zap.Int64("newManagerId", newManagerId))
+	return emp, nil
+}
+
+func (e *employeestore) moveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error) {
+	emp, found, err := e.fetch(id)
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, errors.Wrapf(ErrEmployeeNotFound, "employee %d", id)
+	}
+	if newManagerId == id {
+		return nil, errors.Wrapf(ErrHierarchyCycle, "employee %d can't manage itself", id)
+	}
+	if err := checkRootMove(emp, newManagerId, moveReports); err != nil {
+		return nil, err
+	}
+	if newManagerId != 0 {
+		chain, err := e.getManagerChain(newManagerId)
+		if errors.Cause(err) == ErrEmployeeNotFound {
+			return nil, errors.Wrapf(ErrEmployeeNotFound, "manager %d", newManagerId)
+		} else if err != nil {
+			return nil, err
+		}
+		//reports handed to the old manager leave the subtree, so only moving them along can close a loop
+		if moveReports && inChain(id, chain) {
+			return nil, errors.Wrapf(ErrHierarchyCycle, "employee %d manages %d", id, newManagerId)
+		}
+	}
+	if emp.ManagerId == newManagerId {
+		return emp, nil
+	}
+	batch := e.dbSession.Batch(gocql.LoggedBatch)
+	batch.Query(SETMANAGER, newManagerId, id)
+	if emp.ManagerId != 0 {
+		batch.Query(REMOVEREPORT, []int64{id}, emp.ManagerId)
+	}
+	if newManagerId != 0 {
+		batch.Query(ADDREPORT, []int64{id}, newManagerId)
+	}
+	if !moveReports && len(emp.Reports) > 0 {
+		batch.Query(REMOVEREPORT, emp.Reports, id)
+		for _, report := range emp.Reports {
+			batch.Query(SETMANAGER, emp.ManagerId, report)
+		}
+		if emp.ManagerId != 0 {
+			batch.Query(ADDREPORT, emp.Reports, emp.ManagerId)
+		}
+		emp.Reports = []int64{}
+	}
+	if err := batch.ExecuteBatch(); err != nil {
+		return nil, dbError(err, "move employee")
+	}
+	emp.ManagerId = newManagerId
+	return emp, nil
+}
+
+//Write whole rows with logged batches of at most MAXPUTBATCH rows, each batch is applied atomically
+func (e *employeestore) PutEmployees(employees []*Employee) error {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("putemployees"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Putting employees", zap.Int("count", len(employees)))
+	err := e.putEmployees(employees)
+	reqCount.WithLabelValues(resultLabel(err), "putemployees").Inc()
+	if err != nil {
+		return err
+	}
+	e.logger.Debug("EmployeeDB: Success putting employees", zap.Int("count", len(employees)))
+	return nil
+}
+
+func (e *employeestore) putEmployees(employees []*Employee) error {
+	for start := 0; start < len(employees); start += MAXPUTBATCH {
+		end := start + MAXPUTBATCH
+		if end > len(employees) {
+			end = len(employees)
+		}
+		batch := e.dbSession.Batch(gocql.LoggedBatch)
+		for _, emp := range employees[start:end] {
+			batch.Query(INSERTEMPLOYEE, emp.Id, emp.Name, emp.Title, emp.Reports, emp.ManagerId)
+		}
+		if err := batch.ExecuteBatch(); err != nil {
+			return dbError(err, "put employees")
+		}
+		for _, emp := range employees[start:end] {
+			e.search.put(emp)
+		}
+	}
+	return nil
+}
+
+//Walk manager_id links from an employee up to the root
+func (e *employeestore) GetManagerChain(id *EmployeeId) ([]*Employee, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("getmanagerchain"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Fetching manager chain", zap.Int64("empId", id.Id))
+	chain, err := e.getManagerChain(id.Id)
+	reqCount.WithLabelValues(resultLabel(err), "getmanagerchain").Inc()
+	if err != nil {
+		return nil, err
+	}
+	e.logger.Debug("EmployeeDB: Success fetching manager chain", zap.Int64("empId", id.Id), zap.Int("managers", len(chain)))
+	return chain, nil
+}
+
+func (e *employeestore) getManagerChain(id int64) ([]*Employee, error) {
+	emp, found, err := e.fetch(id)
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, errors.Wrapf(ErrEmployeeNotFound, "employee %d", id)
+	}
+	chain := []*Employee{}
+	visited := map[int64]bool{id: true}
+	for emp.ManagerId != 0 && !visited[emp.ManagerId] {
+		visited[emp.ManagerId] = true
+		managerId := emp.ManagerId
+		if emp, found, err = e.fetch(managerId); err != nil {
+			return nil, err
+		} else if !found {
+			e.logger.Warn("EmployeeDB: Manager not found, chain is incomplete", zap.Int64("empId", id), zap.Int64("managerId", managerId))
+			break
+		}
+		chain = append(chain, emp)
+	}
+	return chain, nil
+}
+
+//List one page of employees, pages are read from cassandra until pageSize employees match the filter
+func (e *employeestore) ListEmployees(filter *EmployeeFilter, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("listemployees"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Listing employees", zap.Int("pageSize", pageSize), zap.String("title", filter.Title), zap.String("namePrefix", filter.NamePrefix))
+	employees, next, err := e.listEmployees(filter, pageSize, pageState)
+	reqCount.WithLabelValues(resultLabel(err), "listemployees").Inc()
+	if err != nil {
+		return nil, nil, err
+	}
+	e.logger.Debug("EmployeeDB: Success listing employees", zap.Int("employees", len(employees)), zap.Bool("last", len(next) == 0))
+	return employees, next, nil
+}
+
+func (e *employeestore) listEmployees(filter *EmployeeFilter, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	employees := []*Employee{}
+	//the paging state is a position in the table, so each read asks only for the employees still missing
+	for {
+		iter := e.dbSession.Query(LISTEMPLOYEES).PageSize(pageSize - len(employees)).PageState(pageState).Iter()
+		emp := &Employee{}
+		for iter.Scan(&emp.Id, &emp.Name, &emp.Title, &emp.Reports, &emp.ManagerId) {
+			if filter.Match(emp) {
+				employees = append(employees, emp)
+			}
+			emp = &Employee{}
+		}
+		state := iter.PageState()
+		if err := iter.Close(); err != nil {
+			if len(pageState) > 0 && c.IsProtocolError(err) {
+				return nil, nil, errors.Wrap(ErrInvalidPageToken, err.Error())
+			}
+			return nil, nil, dbError(err, "list employees")
+		}
+		pageState = state
+		if len(pageState) == 0 || len(employees) >= pageSize {
+			return employees, pageState, nil
+		}
+	}
+}
+
+//Search employees by name and title in the search index, unavailable until the index was built once
+func (e *employeestore) SearchEmployees(query *SearchQuery, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	timer := prometheus.NewTimer(reqLatency.WithLabelValues("searchemployees"))
+	defer timer.ObserveDuration()
+	e.logger.Debug("EmployeeDB: Searching employees", zap.String("name", query.Name), zap.String("title", query.Title))
+	employees, next, err := e.searchEmployees(query, pageSize, pageState)
+	reqCount.WithLabelValues(resultLabel(err), "searchemployees").Inc()
+	if err != nil {
+		return nil, nil, err
+	}
+	e.logger.Debug("EmployeeDB: Success searching employees", zap.Int("employees", len(employees)), zap.Bool("last", len(next) == 0))
+	return employees, next, nil
+}
+
+func (e *employeestore) searchEmployees(query *SearchQuery, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	if _, built := e.search.age(); !built {
+		return nil, nil, errors.WithMessage(&storeError{ErrStoreUnavailable, errors.New("search index not built yet")}, "search employees")
+	}
+	ids, next, err := searchPage(e.search.search(query), pageSize, pageState)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := make(map[int64]*Employee, len(ids))
+	if err := e.fetchAll(ids, found); err != nil {
+		return nil, nil, err
+	}
+	//employees deleted through another replica since the last rebuild are left out
+	employees, _ := orderEmployees(ids, found)
+	return employees, next, nil
+}
+
+//startSearchRefresh rebuilds the search index every searchRefresh until Close, a failed rebuild
+//keeps the index in use
+func (e *employeestore) startSearchRefresh() {
+	e.stopRefresh, e.refreshStopped = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(e.refreshStopped)
+		ticker := time.NewTicker(e.searchRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.rebuildSearchIndex(); err != nil {
+					e.logger.Error("EmployeeDB: Failed to rebuild search index, keeping the one in use", zap.Error(err))
+				}
+			case <-e.stopRefresh:
+				return
+			}
+		}
+	}()
+}
+
+//rebuildSearchIndex reads the whole employee table into a new search index, writes made through this
+//store while the table is read are applied to the new index before it replaces the one in use
+func (e *employeestore) rebuildSearchIndex() error {
+	e.search.startRebuild()
+	employees := []*Employee{}
+	var pageState []byte
+	for {
+		page, next, err := e.listEmployees(&EmployeeFilter{}, MAXPAGESIZE, pageState)
+		if err != nil {
+			e.search.abortRebuild()
+			return errors.WithMessage(err, "rebuild search index")
+		}
+		employees = append(employees, page...)
+		if len(next) == 0 {
+			break
+		}
+		pageState = next
+	}
+	e.search.reset(employees)
+	e.logger.Info("EmployeeDB: Search index rebuilt", zap.Int("employees", len(employees)))
+	return nil
+}
+
+//checkRootMove refuses to move a root without its reports, they would be left without a manager as
+//several new roots
+func checkRootMove(emp *Employee, newManagerId int64, moveReports bool) error {
+	if emp.ManagerId == 0 && newManagerId != 0 && !moveReports && len(emp.Reports) > 0 {
+		return errors.Wrapf(ErrInvalidMove, "employee %d has no manager, its reports must move along", emp.Id)
+	}
+	return nil
+}
+
+//inChain reports whether id is one of the managers in chain
+func inChain(id int64, chain []*Employee) bool {
+	for _, manager := range chain {
+		if manager.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+//uniqueIds returns ids without duplicates, keeping the first occurrence
+func uniqueIds(ids []int64) []int64 {
+	seen := make(map[int64]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+//orderEmployees lists the found employees in the order of ids, with the ids that weren't found
+func orderEmployees(ids []int64, found map[int64]*Employee) ([]*Employee, []int64) {
+	employees := make([]*Employee, 0, len(found))
+	missing := []int64{}
+	for _, id := range ids {
+		if emp, ok := found[id]; ok {
+			employees = append(employees, emp)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return employees, missing
+}
+
+//storeError classifies a database error as ErrStoreUnavailable or ErrStoreTimeout,
+//errors.Cause returns the classification while Error keeps the database message
+type storeError struct {
+	kind error
+	err  error
+}
+
+func (e *storeError) Error() string { return e.kind.Error() + ": " + e.err.Error() }
+func (e *storeError) Cause() error  { return e.kind }
+
+//dbError annotates a database error with msg, classifying timeouts and unavailability
+func dbError(err error, msg string) error {
+	switch {
+	case c.IsTimeout(err):
+		return errors.WithMessage(&storeError{ErrStoreTimeout, err}, msg)
+	case c.IsUnavailable(err):
+		return errors.WithMessage(&storeError{ErrStoreUnavailable, err}, msg)
+	default:
+		return errors.Wrap(err, msg)
+	}
+}
+
+//resultLabel is the db_requests_total result label for the error returned by a store method
+func resultLabel(err error) string {
+	switch errors.Cause(err) {
+	case nil:
+		return "success"
+	case ErrEmployeeNotFound:
+		return "notfound"
+	case ErrEmployeeExists, ErrEmployeeHasReports, ErrHierarchyCycle:
+		return "conflict"
+	case ErrStoreUnavailable:
+		return "unavailable"
+	case ErrStoreTimeout:
+		return "timeout"
+	case ErrInvalidPageToken, ErrInvalidMove:
+		return "invalid"
+	default:
+		return "failure"
+	}
+}
+
 //Close dbsession
 func (e *employeestore) Close() {
+	if e.stopRefresh != nil {
+		close(e.stopRefresh)
+		<-e.refreshStopped
+	}
 	e.logger.Info("EmployeeDB: Closing satabase session")
 	e.dbSession.Close()
 }
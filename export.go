@@ -0,0 +1,219 @@
+package hrapp
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	//Most bytes sent in one ExportChunk
+	EXPORTCHUNKSIZE = 32 * 1024
+)
+
+//ExportFormats are the formats WriteOrg renders
+var ExportFormats = []string{"json", "yaml", "csv", "dot", "mermaid", "text"}
+
+//ValidExportFormat reports whether WriteOrg renders format
+func ValidExportFormat(format string) bool {
+	for _, f := range ExportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+//WriteOrg renders tree to w in format. managerId is the manager of the tree root, written to the manager_id
+//column of CSV so that the export can be imported back, 0 leaves it empty
+func WriteOrg(w io.Writer, format string, tree *ReportingTree, managerId int64) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case "json":
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(toHierarchyNode(tree)); err != nil {
+			return err
+		}
+	case "yaml":
+		enc := yaml.NewEncoder(bw)
+		if err := enc.Encode(toHierarchyNode(tree)); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	case "csv":
+		if err := writeOrgCSV(bw, tree, managerId); err != nil {
+			return err
+		}
+	case "dot":
+		writeOrgDOT(bw, tree)
+	case "mermaid":
+		writeOrgMermaid(bw, tree)
+	case "text":
+		fmt.Fprintf(bw, "%s\n", textLabel(tree))
+		writeOrgText(bw, tree, "")
+	default:
+		return errors.Errorf("unknown export format %q", format)
+	}
+	return bw.Flush()
+}
+
+//ExportOrg renders the hierarchy below the requested employee and streams it in chunks of at most EXPORTCHUNKSIZE bytes
+func (s *ServiceImpl) ExportOrg(req *ExportOrgRequest, stream Hrapp_ExportOrgServer) error {
+	s.logger.Debug("gRPC: ExportOrg called", zap.Int64("empId", req.Id), zap.Int32("maxDepth", req.MaxDepth), zap.String("format", req.Format))
+	format := req.Format
+	if format == "" {
+		format = "json"
+	}
+	if !ValidExportFormat(format) {
+		return status.Errorf(codes.InvalidArgument, "unknown format %q, expected one of %s", req.Format, strings.Join(ExportFormats, ", "))
+	}
+	root, tree, err := s.reportingTree(stream.Context(), req.Id, req.MaxDepth)
+	if err != nil {
+		return err
+	}
+	w := &chunkWriter{stream: stream}
+	if err := WriteOrg(w, format, tree, root.ManagerId); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return w.flush()
+}
+
+//chunkWriter sends what is written to it as ExportChunk messages of EXPORTCHUNKSIZE bytes, flush sends the rest
+type chunkWriter struct {
+	stream Hrapp_ExportOrgServer
+	buf    []byte
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		room := EXPORTCHUNKSIZE - len(c.buf)
+		if room > len(p) {
+			room = len(p)
+		}
+		c.buf = append(c.buf, p[:room]...)
+		p = p[room:]
+		if len(c.buf) == EXPORTCHUNKSIZE {
+			if err := c.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (c *chunkWriter) flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	err := c.stream.Send(&ExportChunk{Data: c.buf})
+	c.buf = nil
+	return err
+}
+
+func toHierarchyNode(tree *ReportingTree) *hierarchyNode {
+	node := &hierarchyNode{Id: tree.Id, Name: tree.Name, Title: tree.Title, Reports: make([]*hierarchyNode, len(tree.Reports))}
+	for i, report := range tree.Reports {
+		node.Reports[i] = toHierarchyNode(report)
+	}
+	return node
+}
+
+//writeOrgCSV writes id,name,title,manager_id records depth first, the columns ParseEmployeesCSV reads
+func writeOrgCSV(w io.Writer, tree *ReportingTree, managerId int64) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "title", "manager_id"})
+	var write func(node *ReportingTree, managerId int64)
+	write = func(node *ReportingTree, managerId int64) {
+		manager := ""
+		if managerId != 0 {
+			manager = strconv.FormatInt(managerId, 10)
+		}
+		cw.Write([]string{strconv.FormatInt(node.Id, 10), node.Name, node.Title, manager})
+		for _, report := range node.Reports {
+			write(report, node.Id)
+		}
+	}
+	write(tree, managerId)
+	cw.Flush()
+	return cw.Error()
+}
+
+//writeOrgDOT writes a Graphviz digraph with one box per employee and an edge from every manager to its reports
+func writeOrgDOT(w io.Writer, tree *ReportingTree) {
+	fmt.Fprint(w, "digraph org {\n    node [shape=box];\n")
+	walkOrg(tree, func(node *ReportingTree) {
+		fmt.Fprintf(w, "    %d [label=\"%s\\n%s\"];\n", node.Id, dotEscape(node.Name), dotEscape(node.Title))
+	})
+	walkOrg(tree, func(node *ReportingTree) {
+		for _, report := range node.Reports {
+			fmt.Fprintf(w, "    %d -> %d;\n", node.Id, report.Id)
+		}
+	})
+	fmt.Fprint(w, "}\n")
+}
+
+//writeOrgMermaid writes a top down Mermaid flowchart, node ids are e followed by the employee id
+func writeOrgMermaid(w io.Writer, tree *ReportingTree) {
+	fmt.Fprint(w, "graph TD\n")
+	walkOrg(tree, func(node *ReportingTree) {
+		fmt.Fprintf(w, "    e%d[\"%s<br/>%s\"]\n", node.Id, mermaidEscape(node.Name), mermaidEscape(node.Title))
+	})
+	walkOrg(tree, func(node *ReportingTree) {
+		for _, report := range node.Reports {
+			fmt.Fprintf(w, "    e%d --> e%d\n", node.Id, report.Id)
+		}
+	})
+}
+
+//writeOrgText writes the reports of node one per line, indented below their manager with tree drawing characters
+func writeOrgText(w io.Writer, node *ReportingTree, indent string) {
+	for i, report := range node.Reports {
+		branch, next := "├── ", "│   "
+		if i == len(node.Reports)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%s%s%s\n", indent, branch, textLabel(report))
+		writeOrgText(w, report, indent+next)
+	}
+}
+
+func textLabel(node *ReportingTree) string {
+	if node.Title == "" {
+		return fmt.Sprintf("%s [%d]", node.Name, node.Id)
+	}
+	return fmt.Sprintf("%s, %s [%d]", node.Name, node.Title, node.Id)
+}
+
+//walkOrg calls fn for every node of tree depth first, managers before their reports
+func walkOrg(tree *ReportingTree, fn func(*ReportingTree)) {
+	fn(tree)
+	for _, report := range tree.Reports {
+		walkOrg(report, fn)
+	}
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotEscape(s string) string {
+	return dotEscaper.Replace(s)
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ")
+
+func mermaidEscape(s string) string {
+	return mermaidEscaper.Replace(s)
+}
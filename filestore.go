@@ -0,0 +1,344 @@
+package hrapp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	SNAPSHOTFILE = "snapshot.json"
+	LOGFILE      = "employees.log"
+	//Default number of logged writes after which the log is compacted into a new snapshot
+	SNAPSHOTEVERY = 1000
+)
+
+//filestore persists the memory store on local disk for single node deployments,
+//every write is checked against the memory store, appended to a log and fsynced
+//before it is applied, the log is folded into a snapshot every snapshotEvery writes
+//and on Close
+type filestore struct {
+	*memorystore
+	mu            sync.Mutex
+	dir           string
+	log           *os.File
+	seq           int64
+	pending       int
+	snapshotEvery int
+}
+
+//logEntry is one line of the write ahead log
+type logEntry struct {
+	Seq       int64     `json:"seq"`
+	Op        string    `json:"op"`
+	Id        int64     `json:"id,omitempty"`
+	Employee  *Employee `json:"employee,omitempty"`
+	ManagerId int64     `json:"manager_id,omitempty"`
+	//MoveReports tells a move whether the reports go along with the employee
+	MoveReports bool `json:"move_reports,omitempty"`
+	//Employees are the rows written by a put
+	Employees []*Employee `json:"employees,omitempty"`
+}
+
+//snapshot is the content of the snapshot file, seq is the last log entry it includes
+type snapshot struct {
+	Seq       int64       `json:"seq"`
+	Employees []*Employee `json:"employees"`
+}
+
+//FileStoreInit opens the store kept in dir, creating it if needed. A new store is
+//seeded from seedFile, a JSON array of employees, when it is set
+func FileStoreInit(logger *zap.Logger, dir string, seedFile string, snapshotEvery int) (EmployeeStore, error) {
+	logger.Info("DataAccess: Opening file employee store", zap.String("dir", dir))
+	if snapshotEvery <= 0 {
+		snapshotEvery = SNAPSHOTEVERY
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "DataAccess: Failed to create data directory")
+	}
+	f := &filestore{dir: dir, snapshotEvery: snapshotEvery}
+	snap, err := f.readSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case snap != nil:
+		f.memorystore = NewMemoryStore(logger, snap.Employees).(*memorystore)
+		f.seq = snap.Seq
+	case seedFile != "":
+		seeded, err := MemoryStoreInit(logger, seedFile)
+		if err != nil {
+			return nil, err
+		}
+		f.memorystore = seeded.(*memorystore)
+		if err := f.snapshot(); err != nil {
+			return nil, errors.Wrap(err, "DataAccess: Failed to write seeded snapshot")
+		}
+	default:
+		f.memorystore = NewMemoryStore(logger, nil).(*memorystore)
+	}
+	if err := f.replay(); err != nil {
+		return nil, err
+	}
+	logger.Info("DataAccess: File employee store opened", zap.Int("employees", len(f.employees)), zap.Int64("seq", f.seq))
+	return f, nil
+}
+
+func (f *filestore) CreateEmployee(emp *Employee, managerId int64) error {
+	_, err := f.write(&logEntry{Op: "create", Employee: emp, ManagerId: managerId})
+	return err
+}
+
+func (f *filestore) UpdateEmployee(emp *Employee) error {
+	_, err := f.write(&logEntry{Op: "update", Employee: emp})
+	return err
+}
+
+func (f *filestore) DeleteEmployee(id *EmployeeId) (*Employee, error) {
+	return f.write(&logEntry{Op: "delete", Id: id.Id})
+}
+
+func (f *filestore) MoveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error) {
+	return f.write(&logEntry{Op: "move", Id: id, ManagerId: newManagerId, MoveReports: moveReports})
+}
+
+func (f *filestore) PutEmployees(employees []*Employee) error {
+	_, err := f.write(&logEntry{Op: "put", Employees: employees})
+	return err
+}
+
+//Close folds the log into a snapshot so the next start doesn't replay it
+func (f *filestore) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending > 0 {
+		if err := f.snapshot(); err != nil {
+			f.logger.Error("FileStore: Failed to write snapshot on close", zap.Error(err))
+		}
+	}
+	if f.log != nil {
+		f.log.Close()
+	}
+	f.memorystore.Close()
+}
+
+//write logs entry durably and then applies it. Entries that would fail to apply are
+//refused before they reach the log, f.mu keeps other writes from changing the store in between
+func (f *filestore) write(entry *logEntry) (*Employee, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.check(entry); err != nil {
+		return nil, err
+	}
+	entry.Seq = f.seq + 1
+	if err := f.append(entry); err != nil {
+		return nil, errors.WithMessage(&storeError{ErrStoreUnavailable, err}, "append to log")
+	}
+	f.seq = entry.Seq
+	f.pending++
+	emp, err := f.apply(entry)
+	if f.pending >= f.snapshotEvery {
+		if err := f.snapshot(); err != nil {
+			f.logger.Error("FileStore: Failed to write snapshot", zap.Error(err))
+		}
+	}
+	return emp, err
+}
+
+func (f *filestore) apply(entry *logEntry) (*Employee, error) {
+	switch entry.Op {
+	case "create":
+		return nil, f.memorystore.CreateEmployee(entry.Employee, entry.ManagerId)
+	case "update":
+		return nil, f.memorystore.UpdateEmployee(entry.Employee)
+	case "delete":
+		return f.memorystore.DeleteEmployee(&EmployeeId{Id: entry.Id})
+	case "move":
+		return f.memorystore.MoveEmployee(entry.Id, entry.ManagerId, entry.MoveReports)
+	case "put":
+		return nil, f.memorystore.PutEmployees(entry.Employees)
+	default:
+		return nil, errors.Errorf("unknown log operation %q", entry.Op)
+	}
+}
+
+//check returns the error applying entry to the memory store would fail with
+func (f *filestore) check(entry *logEntry) error {
+	f.memorystore.mu.RLock()
+	defer f.memorystore.mu.RUnlock()
+	switch entry.Op {
+	case "create":
+		return f.memorystore.checkCreate(entry.Employee, entry.ManagerId)
+	case "update":
+		return f.memorystore.checkUpdate(entry.Employee)
+	case "delete":
+		return f.memorystore.checkDelete(entry.Id)
+	case "move":
+		return f.memorystore.checkMove(entry.Id, entry.ManagerId, entry.MoveReports)
+	case "put":
+		return nil
+	default:
+		return errors.Errorf("unknown log operation %q", entry.Op)
+	}
+}
+
+//append writes entry at the end of the log and fsyncs it, a failed write or sync is cut off the log
+//so that replay doesn't apply an entry that was reported as failed
+func (f *filestore) append(entry *logEntry) error {
+	if f.log == nil {
+		log, err := os.OpenFile(filepath.Join(f.dir, LOGFILE), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		f.log = log
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	info, err := f.log.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err = f.log.Write(append(b, '\n')); err == nil {
+		err = f.log.Sync()
+	}
+	if err != nil {
+		if terr := f.log.Truncate(info.Size()); terr != nil {
+			f.logger.Error("FileStore: Failed to truncate log after a failed append", zap.Int64("offset", info.Size()), zap.Error(terr))
+		}
+		return err
+	}
+	return nil
+}
+
+//replay applies the log entries newer than the snapshot. The log is cut off at the first line that
+//doesn't parse, a torn last line left by a crash or a corrupt entry, the entries after it are lost
+func (f *filestore) replay() error {
+	path := filepath.Join(f.dir, LOGFILE)
+	file, err := os.OpenFile(path, os.O_RDWR, 0644)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "DataAccess: Failed to open log")
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				f.logger.Warn("FileStore: Discarding incomplete last log entry", zap.Int64("offset", offset))
+				return f.truncateLog(file, offset)
+			}
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "DataAccess: Failed to read log")
+		}
+		entry := &logEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			f.logger.Error("FileStore: Corrupt log entry, discarding it and the entries after it", zap.Int64("offset", offset), zap.Error(err))
+			return f.truncateLog(file, offset)
+		}
+		offset += int64(len(line))
+		if entry.Seq <= f.seq {
+			continue
+		}
+		if _, err := f.apply(entry); err != nil {
+			f.logger.Debug("FileStore: Replayed entry failed as it did originally", zap.Int64("seq", entry.Seq), zap.Error(err))
+		}
+		f.seq = entry.Seq
+		f.pending++
+	}
+}
+
+//truncateLog cuts the log off at offset and fsyncs it
+func (f *filestore) truncateLog(file *os.File, offset int64) error {
+	if err := file.Truncate(offset); err != nil {
+		return errors.Wrap(err, "DataAccess: Failed to truncate log")
+	}
+	if err := file.Sync(); err != nil {
+		return errors.Wrap(err, "DataAccess: Failed to sync log")
+	}
+	return nil
+}
+
+func (f *filestore) readSnapshot() (*snapshot, error) {
+	b, err := ioutil.ReadFile(filepath.Join(f.dir, SNAPSHOTFILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "DataAccess: Failed to read snapshot")
+	}
+	snap := &snapshot{}
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, errors.Wrap(err, "DataAccess: Failed to parse snapshot")
+	}
+	return snap, nil
+}
+
+//snapshot atomically replaces the snapshot file with the current state and starts a new log
+func (f *filestore) snapshot() error {
+	f.memorystore.mu.RLock()
+	snap := &snapshot{Seq: f.seq, Employees: make([]*Employee, 0, len(f.employees))}
+	for _, emp := range f.employees {
+		snap.Employees = append(snap.Employees, copyEmployee(emp))
+	}
+	f.memorystore.mu.RUnlock()
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(f.dir, SNAPSHOTFILE+".tmp")
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(b); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(f.dir, SNAPSHOTFILE)); err != nil {
+		return err
+	}
+	//the rename must be durable before the log goes, a crash would otherwise find the old snapshot and an empty log
+	if err := syncDir(f.dir); err != nil {
+		return err
+	}
+	//entries up to seq are in the snapshot now, replay skips them even if truncating fails
+	if f.log != nil {
+		f.log.Close()
+		f.log = nil
+	}
+	if err := os.Truncate(filepath.Join(f.dir, LOGFILE), 0); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f.pending = 0
+	f.logger.Info("FileStore: Snapshot written", zap.Int64("seq", f.seq), zap.Int("employees", len(snap.Employees)))
+	return nil
+}
+
+//syncDir fsyncs dir so that renames and creations of its files survive a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,284 @@
+package grpcserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	//Default time between two checks of the certificate files for changes
+	CERTWATCHINTERVAL = 10 * time.Second
+)
+
+var (
+	certExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "tls_certificate_expiry_timestamp_seconds",
+			Help: "Unix time after which the certificate in use is no longer valid, partitioned by cert (server, client_ca). The CA is the first of the client CAs to expire.",
+		},
+		[]string{"cert"},
+	)
+	certReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "tls_certificate_reloads_total",
+			Help: "How many times changed certificate files were loaded, partitioned by result (success, failure)",
+		},
+		[]string{"result"},
+	)
+)
+
+//CertManager serves the server certificate and the client CAs read from the files of a TlsConfig. It checks
+//the files for changes every watch interval and swaps in the new certificates once they all load, files
+//that fail to load keep the certificates in use and are tried again at the next check
+type CertManager struct {
+	logger   *zap.Logger
+	interval time.Duration
+	mu       sync.RWMutex
+	config   TlsConfig
+	tls      *tls.Config
+	cert     *tls.Certificate
+	//stamps are the sizes and modification times of the files currently loaded
+	stamps []fileStamp
+	//resetWatch tells Watch the interval changed
+	resetWatch chan struct{}
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+//NewCertManager loads the certificate, key and CA files of config, it fails when any of them doesn't load
+func NewCertManager(logger *zap.Logger, config *TlsConfig) (*CertManager, error) {
+	m := &CertManager{logger: logger, interval: watchInterval(config), resetWatch: make(chan struct{}, 1), stop: make(chan struct{})}
+	if err := m.load(config); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+//TLSConfig returns the server side tls configuration, every handshake gets the certificates in use at that time
+func (m *CertManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetConfigForClient: m.GetConfigForClient}
+}
+
+//GetConfigForClient returns the tls configuration with the client CAs in use, its certificate is served by
+//GetCertificate
+func (m *CertManager) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tls, nil
+}
+
+//GetCertificate returns the server certificate in use
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert, nil
+}
+
+//ExpiryCheck fails when the server certificate in use is not valid yet or has expired
+func (m *CertManager) ExpiryCheck() error {
+	cert, _ := m.GetCertificate(nil)
+	now := time.Now()
+	if now.Before(cert.Leaf.NotBefore) || now.After(cert.Leaf.NotAfter) {
+		return errors.Errorf("server certificate is valid from %s until %s", cert.Leaf.NotBefore.Format(time.RFC3339), cert.Leaf.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+//Reload loads the files of config, which may name other files than the ones in use, and watches them from now on
+func (m *CertManager) Reload(config *TlsConfig) error {
+	apply, err := m.PrepareReload(config)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+//PrepareReload loads the files of config without using them, the returned function swaps them in and
+//watches them from then on at the watch interval of config
+func (m *CertManager) PrepareReload(config *TlsConfig) (func(), error) {
+	apply, err := m.prepare(config)
+	if err != nil {
+		certReloads.WithLabelValues("failure").Inc()
+		return nil, err
+	}
+	return func() {
+		apply()
+		certReloads.WithLabelValues("success").Inc()
+	}, nil
+}
+
+//Watch checks the files for changes until Stop is called, a reload changing the watch interval restarts the ticker
+func (m *CertManager) Watch() {
+	m.mu.RLock()
+	ticker := time.NewTicker(m.interval)
+	m.mu.RUnlock()
+	defer func() { ticker.Stop() }()
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.resetWatch:
+			ticker.Stop()
+			m.mu.RLock()
+			ticker = time.NewTicker(m.interval)
+			m.mu.RUnlock()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+//Stop ends Watch
+func (m *CertManager) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+//check reloads the files when any of them changed since they were loaded
+func (m *CertManager) check() {
+	m.mu.RLock()
+	config, loaded := m.config, m.stamps
+	m.mu.RUnlock()
+	stamps, err := statFiles(&config)
+	if err != nil {
+		m.logger.Warn("gRPCServer: Failed to check certificate files", zap.Error(err))
+		return
+	}
+	changed := false
+	for i := range stamps {
+		changed = changed || stamps[i] != loaded[i]
+	}
+	if !changed {
+		return
+	}
+	if err := m.Reload(&config); err != nil {
+		m.logger.Error("gRPCServer: Failed to reload changed certificate files, keeping the certificates in use", zap.Error(err))
+	}
+}
+
+//load reads the files of config and swaps them in when they all load
+func (m *CertManager) load(config *TlsConfig) error {
+	apply, err := m.prepare(config)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+//prepare reads the files of config, the returned function swaps them in
+func (m *CertManager) prepare(config *TlsConfig) (func(), error) {
+	stamps, err := statFiles(config)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := loadCertificates(config)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		GetCertificate: m.GetCertificate,
+		ClientCAs:      certs.clientCAs,
+		NextProtos:     []string{"h2"},
+	}
+	interval := watchInterval(config)
+	return func() {
+		leaf := certs.cert.Leaf
+		if time.Now().After(leaf.NotAfter) {
+			m.logger.Warn("gRPCServer: Server certificate has expired", zap.String("cert", config.CertPath), zap.Time("notAfter", leaf.NotAfter))
+		}
+		m.mu.Lock()
+		m.config, m.tls, m.cert, m.stamps = *config, tlsConfig, certs.cert, stamps
+		intervalChanged := interval != m.interval
+		m.interval = interval
+		m.mu.Unlock()
+		if intervalChanged {
+			select {
+			case m.resetWatch <- struct{}{}:
+			default:
+			}
+			m.logger.Info("gRPCServer: Certificate watch interval changed", zap.Duration("interval", interval))
+		}
+		certExpiry.WithLabelValues("server").Set(float64(leaf.NotAfter.Unix()))
+		certExpiry.WithLabelValues("client_ca").Set(float64(certs.caNotAfter.Unix()))
+		m.logger.Info("gRPCServer: Loaded tls certificates", zap.String("cert", config.CertPath), zap.String("ca", config.CAPath), zap.Time("notAfter", leaf.NotAfter))
+	}, nil
+}
+
+//watchInterval returns the watch interval of config, CERTWATCHINTERVAL when unset
+func watchInterval(config *TlsConfig) time.Duration {
+	if config.WatchInterval <= 0 {
+		return CERTWATCHINTERVAL
+	}
+	return config.WatchInterval
+}
+
+//certificates are the files of a TlsConfig once loaded
+type certificates struct {
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	//caNotAfter is when the first of the client CAs expires
+	caNotAfter time.Time
+}
+
+//loadCertificates reads the server certificate and the CAs verifying client certificates
+func loadCertificates(c *TlsConfig) (*certificates, error) {
+	certificate, err := tls.LoadX509KeyPair(c.CertPath, c.KeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load server certificate")
+	}
+	if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse server certificate")
+	}
+	bs, err := ioutil.ReadFile(c.CAPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read client ca cert")
+	}
+	certs := &certificates{cert: &certificate, clientCAs: x509.NewCertPool()}
+	for block, rest := pem.Decode(bs); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse client ca cert %s", c.CAPath)
+		}
+		certs.clientCAs.AddCert(ca)
+		if certs.caNotAfter.IsZero() || ca.NotAfter.Before(certs.caNotAfter) {
+			certs.caNotAfter = ca.NotAfter
+		}
+	}
+	if certs.caNotAfter.IsZero() {
+		return nil, errors.Errorf("Failed to append client certs, no certificate found in %s", c.CAPath)
+	}
+	return certs, nil
+}
+
+//statFiles returns the stamps of the CA, certificate and key files
+func statFiles(c *TlsConfig) ([]fileStamp, error) {
+	paths := []string{c.CAPath, c.CertPath, c.KeyPath}
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to read certificate file")
+		}
+		stamps[i] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+	}
+	return stamps, nil
+}
@@ -1,19 +1,23 @@
 package grpcserver
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
 	"github.com/nilangshah/hrapp/util"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapgrpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/grpclog"
-	"io/ioutil"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	//	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	"google.golang.org/grpc"
 	"net"
+	"sync"
+	"time"
 )
 
 type GRPCImpl interface {
@@ -25,15 +29,43 @@ type GRPCImpl interface {
 }
 
 type GRPCConfig struct {
-	ListenAddress string
-	TlsConfig     *TlsConfig
+	ListenAddress string     `config:"listen-address"`
+	TlsConfig     *TlsConfig `config:"tls"`
 }
 
 type TlsConfig struct {
-	TlsEnabled bool
-	CAPath     string
-	CertPath   string
-	KeyPath    string
+	TlsEnabled bool   `config:"enabled"`
+	CAPath     string `config:"ca-path"`
+	CertPath   string `config:"cert-path"`
+	KeyPath    string `config:"key-path"`
+	//WatchInterval is how often the files are checked for changes, CERTWATCHINTERVAL when 0
+	WatchInterval time.Duration `config:"watch-interval"`
+}
+
+//Validate checks that the listen address has a port
+func (c *GRPCConfig) Validate() error {
+	if _, _, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		return errors.Wrapf(err, "listen-address %q", c.ListenAddress)
+	}
+	return nil
+}
+
+//Validate checks that the certificate, key and CA files can be loaded when tls is enabled
+func (c *TlsConfig) Validate() error {
+	if !c.TlsEnabled {
+		return nil
+	}
+	if c.WatchInterval < 0 {
+		return errors.Errorf("watch-interval must not be negative, got %s", c.WatchInterval)
+	}
+	paths := []struct{ name, path string }{{"ca-path", c.CAPath}, {"cert-path", c.CertPath}, {"key-path", c.KeyPath}}
+	for _, p := range paths {
+		if p.path == "" {
+			return errors.Errorf("%s is required when tls is enabled", p.name)
+		}
+	}
+	_, err := loadCertificates(c)
+	return err
 }
 
 type Server struct {
@@ -43,6 +75,16 @@ type Server struct {
 	config             *GRPCConfig
 	logger             *zap.Logger
 	impl               GRPCImpl
+	certs              *CertManager
+	health             *health.Server
+	healthMu           sync.Mutex
+	servingStatus      healthpb.HealthCheckResponse_ServingStatus
+	shuttingDown       bool
+	stopHealth         chan struct{}
+	//listening is closed once the listener is bound, failed receives the error ending Listen or Serve
+	listening chan struct{}
+	failed    chan error
+	serveErr  error
 }
 
 // NewServer creates a GRPC server with supplied options
@@ -60,40 +102,37 @@ func (s *Server) Init(logger *zap.Logger) error {
 	grpclog.SetLogger(zapgrpc.NewLogger(s.logger)) //zapgrpc yet to support loggerV2
 	if s.config.TlsConfig.TlsEnabled {
 		s.logger.Info("gRPCServer: tls enabled, configuring server over tls mutual auth")
-		//Initialize certs
-		certificate, err := tls.LoadX509KeyPair(
-			s.config.TlsConfig.CertPath, //"grpcserver/certs/mydomain.com.crt",
-			s.config.TlsConfig.KeyPath,  // "grpcserver/certs/mydomain.com.key",
-		)
-		certPool := x509.NewCertPool()
-		bs, err := ioutil.ReadFile(s.config.TlsConfig.CAPath) //"grpcserver/certs/root-ca.crt"
+		certs, err := NewCertManager(s.logger, s.config.TlsConfig)
 		if err != nil {
-			s.logger.Error("gRPCServer: Failed to read client ca cert", zap.Error(err))
-		}
-		ok := certPool.AppendCertsFromPEM(bs)
-		if !ok {
-			s.logger.Error("gRPCServer: Failed to append client certs")
-		}
-		tlsConfig := &tls.Config{
-			ClientAuth:   tls.RequireAndVerifyClientCert,
-			Certificates: []tls.Certificate{certificate},
-			ClientCAs:    certPool,
+			return err
 		}
+		s.certs = certs
 		s.grpcServer = grpc.NewServer(
-			grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-			grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
-			grpc.Creds(credentials.NewTLS(tlsConfig)),
+			grpc.UnaryInterceptor(unaryMetricsInterceptor),
+			grpc.StreamInterceptor(streamMetricsInterceptor),
+			grpc.Creds(credentials.NewTLS(s.certs.TLSConfig())),
 		)
 	} else {
 		s.logger.Info("gRPCServer: tls disabled, configuring server insecure")
 		s.grpcServer = grpc.NewServer(
-			grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-			grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor))
+			grpc.UnaryInterceptor(unaryMetricsInterceptor),
+			grpc.StreamInterceptor(streamMetricsInterceptor))
 	}
 
 	s.grpcServer.RegisterService(s.serviceDesc, s.impl)
+	s.health = health.NewServer()
+	s.servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	s.health.SetServingStatus("", s.servingStatus)
+	s.health.SetServingStatus(s.serviceDesc.ServiceName, s.servingStatus)
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	s.stopHealth = make(chan struct{})
+	s.listening = make(chan struct{})
+	s.failed = make(chan error, 1)
 
 	grpc_prometheus.Register(s.grpcServer)
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(grpcReqs, grpcLatency, certExpiry, certReloads)
+	})
 
 	s.rpcShutDownChannel = make(chan bool, 1)
 
@@ -123,36 +162,120 @@ Loop:
 	return nil
 }
 
+//PrepareReload loads the certificate files of config without using them. The returned function serves them
+//to the connections accepted from then on, connections already open keep theirs. A changed listen address or
+//tls setting is logged when applied, it takes effect after a restart
+func (s *Server) PrepareReload(config *GRPCConfig) (func(), error) {
+	applyCerts := func() {}
+	tlsChanged := config.TlsConfig.TlsEnabled != s.config.TlsConfig.TlsEnabled
+	if s.certs != nil && !tlsChanged {
+		var err error
+		if applyCerts, err = s.certs.PrepareReload(config.TlsConfig); err != nil {
+			return nil, err
+		}
+	}
+	return func() {
+		if config.ListenAddress != s.config.ListenAddress {
+			s.logger.Warn("gRPCServer: Listen address changed, restart the service to apply it", zap.String("old", s.config.ListenAddress), zap.String("new", config.ListenAddress))
+		}
+		if tlsChanged {
+			s.logger.Warn("gRPCServer: tls setting changed, restart the service to apply it", zap.Bool("tls-enabled", config.TlsConfig.TlsEnabled))
+		}
+		applyCerts()
+	}, nil
+}
+
 func (s *Server) HandleCommand(cmd string, m *map[string]string) error {
 	switch cmd {
 	case "SHUTDOWN":
+		s.shutdownHealth()
 		s.rpcShutDownChannel <- true
 	default:
 
 	}
 	return nil
 }
+
+//Readiness reports whether the last health check passed and the server is not shutting down
 func (s *Server) Readiness() bool {
-	return true
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	return s.servingStatus == healthpb.HealthCheckResponse_SERVING
 }
 
 func (s *Server) start() {
+	if s.certs != nil {
+		go s.certs.Watch()
+	}
+	go s.watchHealth(s.stopHealth)
 	go func() {
 		l, err := net.Listen("tcp", s.config.ListenAddress)
 
 		if err != nil {
 			s.logger.Error("gRPC Server: Failed to listen on address", zap.Error(err))
+			s.fail(errors.Wrap(err, "Failed to listen on address"))
 			return
 		}
+		close(s.listening)
 
 		if err := s.grpcServer.Serve(l); err != nil {
 			s.logger.Error("gRPC Server: Failed to serve RPC", zap.Error(err))
+			s.fail(errors.Wrap(err, "Failed to serve RPC"))
 			return
 		}
 	}()
 	s.logger.Info("gRPC serevr: Server started", zap.String(util.LACONFIGKEY, s.config.ListenAddress))
 }
 
+//Listening is closed once the server accepts connections
+func (s *Server) Listening() <-chan struct{} {
+	return s.listening
+}
+
+//Failed receives the error that stopped the server from listening or serving
+func (s *Server) Failed() <-chan error {
+	return s.failed
+}
+
+//ServeCheck fails once listening or serving failed
+func (s *Server) ServeCheck(ctx context.Context) error {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	return s.serveErr
+}
+
+//ListenerCheck fails until the listener is bound and after serving failed
+func (s *Server) ListenerCheck(ctx context.Context) error {
+	if err := s.ServeCheck(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-s.listening:
+		return nil
+	default:
+		return errors.Errorf("not listening on %s yet", s.config.ListenAddress)
+	}
+}
+
+//CertificateCheck fails when the server certificate in use has expired, it passes without tls
+func (s *Server) CertificateCheck(ctx context.Context) error {
+	if s.certs == nil {
+		return nil
+	}
+	return s.certs.ExpiryCheck()
+}
+
+func (s *Server) fail(err error) {
+	s.healthMu.Lock()
+	s.serveErr = err
+	s.healthMu.Unlock()
+	s.failed <- err
+}
+
 func (s *Server) stop() {
+	if s.certs != nil {
+		s.certs.Stop()
+	}
+	close(s.stopHealth)
 	s.grpcServer.GracefulStop()
 }
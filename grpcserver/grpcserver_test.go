@@ -0,0 +1,189 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+//writeCert writes a self signed certificate valid until notAfter, its key, and the certificate again as CA
+func writeCert(t *testing.T, config *TlsConfig, notAfter time.Time, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(notAfter.Unix()),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	files := map[string][]byte{
+		config.CertPath: certPem,
+		config.CAPath:   certPem,
+		config.KeyPath:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+	for path, content := range files {
+		assert.Equal(t, nil, ioutil.WriteFile(path, content, 0600))
+		assert.Equal(t, nil, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+func TestCertManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+	config := &TlsConfig{TlsEnabled: true, CAPath: filepath.Join(dir, "ca.crt"), CertPath: filepath.Join(dir, "server.crt"), KeyPath: filepath.Join(dir, "server.key")}
+	start := time.Now().Truncate(time.Second)
+	expiry := start.Add(24 * time.Hour)
+	writeCert(t, config, expiry, start)
+
+	//files that don't load are refused
+	_, err = NewCertManager(zap.NewNop(), &TlsConfig{TlsEnabled: true, CAPath: config.CAPath, CertPath: config.CertPath, KeyPath: config.CAPath})
+	assert.NotEqual(t, nil, err)
+	assert.NotEqual(t, nil, (&TlsConfig{TlsEnabled: true, CAPath: config.KeyPath, CertPath: config.CertPath, KeyPath: config.KeyPath}).Validate())
+	assert.Equal(t, nil, config.Validate())
+
+	m, err := NewCertManager(zap.NewNop(), config)
+	assert.Equal(t, nil, err)
+	cert, _ := m.GetCertificate(nil)
+	assert.Equal(t, expiry.Unix(), cert.Leaf.NotAfter.Unix())
+	assert.Equal(t, float64(expiry.Unix()), testutil.ToFloat64(certExpiry.WithLabelValues("server")))
+	assert.Equal(t, float64(expiry.Unix()), testutil.ToFloat64(certExpiry.WithLabelValues("client_ca")))
+
+	//unchanged files aren't loaded again
+	successes, failures := testutil.ToFloat64(certReloads.WithLabelValues("success")), testutil.ToFloat64(certReloads.WithLabelValues("failure"))
+	m.check()
+	assert.Equal(t, successes, testutil.ToFloat64(certReloads.WithLabelValues("success")))
+
+	//rotated files are served to new connections
+	rotated := start.Add(48 * time.Hour)
+	writeCert(t, config, rotated, start.Add(time.Second))
+	m.check()
+	cert, _ = m.GetCertificate(nil)
+	assert.Equal(t, rotated.Unix(), cert.Leaf.NotAfter.Unix())
+	clientConfig, _ := m.GetConfigForClient(nil)
+	assert.Equal(t, 1, len(clientConfig.ClientCAs.Subjects()))
+	assert.Equal(t, float64(rotated.Unix()), testutil.ToFloat64(certExpiry.WithLabelValues("server")))
+	assert.Equal(t, successes+1, testutil.ToFloat64(certReloads.WithLabelValues("success")))
+
+	//a broken key keeps the certificate in use
+	assert.Equal(t, nil, ioutil.WriteFile(config.KeyPath, []byte("not a key"), 0600))
+	m.check()
+	cert, _ = m.GetCertificate(nil)
+	assert.Equal(t, rotated.Unix(), cert.Leaf.NotAfter.Unix())
+	assert.Equal(t, failures+1, testutil.ToFloat64(certReloads.WithLabelValues("failure")))
+
+	//Reload switches to other files
+	s := &Server{config: &GRPCConfig{ListenAddress: "127.0.0.1:8086", TlsConfig: config}, logger: zap.NewNop(), certs: m}
+	other := &TlsConfig{TlsEnabled: true, CAPath: filepath.Join(dir, "other-ca.crt"), CertPath: filepath.Join(dir, "other.crt"), KeyPath: filepath.Join(dir, "other.key")}
+	writeCert(t, other, expiry, start)
+	apply, err := s.PrepareReload(&GRPCConfig{ListenAddress: "127.0.0.1:8086", TlsConfig: other})
+	assert.Equal(t, nil, err)
+	cert, _ = m.GetCertificate(nil)
+	assert.Equal(t, rotated.Unix(), cert.Leaf.NotAfter.Unix())
+	apply()
+	cert, _ = m.GetCertificate(nil)
+	assert.Equal(t, expiry.Unix(), cert.Leaf.NotAfter.Unix())
+
+	//files that don't load are refused before anything changes
+	_, err = s.PrepareReload(&GRPCConfig{ListenAddress: "127.0.0.1:8086", TlsConfig: &TlsConfig{TlsEnabled: true, CAPath: other.CAPath, CertPath: other.CertPath, KeyPath: filepath.Join(dir, "missing.key")}})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, other.KeyPath, m.config.KeyPath)
+
+	//expired certificates are loaded but fail the expiry check
+	assert.Equal(t, nil, m.ExpiryCheck())
+	writeCert(t, other, start.Add(-time.Minute), start.Add(time.Second))
+	m.check()
+	assert.NotEqual(t, nil, m.ExpiryCheck())
+	assert.NotEqual(t, nil, s.CertificateCheck(context.Background()))
+
+	//a reload shortening the watch interval applies to the running watch
+	done := make(chan struct{})
+	go func() {
+		m.Watch()
+		close(done)
+	}()
+	assert.Equal(t, nil, m.Reload(&TlsConfig{TlsEnabled: true, CAPath: other.CAPath, CertPath: other.CertPath, KeyPath: other.KeyPath, WatchInterval: 10 * time.Millisecond}))
+	writeCert(t, other, expiry, start)
+	for deadline := time.Now().Add(2 * time.Second); m.ExpiryCheck() != nil && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, nil, m.ExpiryCheck())
+	m.Stop()
+	<-done
+}
+
+//fakeImpl is a GRPCImpl without methods whose health is set by the test
+type fakeImpl struct {
+	err error
+}
+
+func (f *fakeImpl) ServiceDesc() *grpc.ServiceDesc {
+	return &grpc.ServiceDesc{ServiceName: "fake", HandlerType: (*interface{})(nil)}
+}
+func (f *fakeImpl) Init(logger *zap.Logger) error { return nil }
+func (f *fakeImpl) Run()                          {}
+func (f *fakeImpl) ShutDown()                     {}
+func (f *fakeImpl) HealthCheck() error            { return f.err }
+
+func TestHealth(t *testing.T) {
+	impl := &fakeImpl{}
+	s := NewServer(&GRPCConfig{ListenAddress: "127.0.0.1:0", TlsConfig: &TlsConfig{}}, impl)
+	assert.Equal(t, nil, s.Init(zap.NewNop()))
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := s.health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		assert.Equal(t, nil, err)
+		return resp.Status
+	}
+
+	//not serving until the first check
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+	s.checkHealth()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, status("fake"))
+	assert.T(t, s.Readiness())
+
+	impl.err = errors.New("cassandra is down")
+	s.checkHealth()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status("fake"))
+	assert.T(t, !s.Readiness())
+
+	//shutdown is final
+	impl.err = nil
+	s.checkHealth()
+	assert.Equal(t, nil, s.HandleCommand("SHUTDOWN", nil))
+	s.checkHealth()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, status(""))
+	assert.T(t, !s.Readiness())
+
+	//the listener check passes once the port is bound
+	assert.NotEqual(t, nil, s.ListenerCheck(context.Background()))
+	s.start()
+	<-s.Listening()
+	assert.Equal(t, nil, s.ListenerCheck(context.Background()))
+	assert.Equal(t, nil, s.ServeCheck(context.Background()))
+	s.stop()
+}
@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	//Time between two health checks of the GRPCImpl dependencies
+	HEALTHCHECKINTERVAL = 5 * time.Second
+)
+
+//HealthChecker is implemented by GRPCImpls whose dependencies can fail, the grpc.health.v1.Health service
+//reports NOT_SERVING while HealthCheck returns an error
+type HealthChecker interface {
+	HealthCheck() error
+}
+
+//watchHealth checks the health of the impl every HEALTHCHECKINTERVAL until stop is closed
+func (s *Server) watchHealth(stop chan struct{}) {
+	ticker := time.NewTicker(HEALTHCHECKINTERVAL)
+	defer ticker.Stop()
+	for {
+		s.checkHealth()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+//checkHealth sets the serving status of the server and of the service, once the server is shutting down
+//the health service keeps reporting NOT_SERVING
+func (s *Server) checkHealth() {
+	var err error
+	if checker, ok := s.impl.(HealthChecker); ok {
+		err = checker.HealthCheck()
+	}
+	status := healthpb.HealthCheckResponse_SERVING
+	if err != nil {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	if s.shuttingDown {
+		return
+	}
+	if status != s.servingStatus {
+		if err != nil {
+			s.logger.Error("gRPCServer: Health check failed, serving status set to NOT_SERVING", zap.Error(err))
+		} else {
+			s.logger.Info("gRPCServer: Health check passed, serving status set to SERVING")
+		}
+	}
+	s.servingStatus = status
+	s.health.SetServingStatus("", status)
+	s.health.SetServingStatus(s.serviceDesc.ServiceName, status)
+}
+
+//shutdownHealth reports NOT_SERVING from now on so that load balancers stop sending requests
+func (s *Server) shutdownHealth() {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	s.shuttingDown = true
+	s.servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	s.health.Shutdown()
+}
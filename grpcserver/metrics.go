@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/nilangshah/hrapp/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	grpcReqs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "How many gRPC requests processed, partitioned by gRPC status code and method.",
+		},
+		[]string{"code", "method"},
+	)
+	grpcLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "Time taken to complete gRPC requests, partitioned by method.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+	registerMetrics sync.Once
+)
+
+//unaryMetricsInterceptor records status code and latency of every unary RPC, then hands over to grpc_prometheus
+func unaryMetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := grpc_prometheus.UnaryServerInterceptor(ctx, req, info, handler)
+	observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+//streamMetricsInterceptor records status code and latency of every streaming RPC, then hands over to grpc_prometheus
+func streamMetricsInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := grpc_prometheus.StreamServerInterceptor(srv, ss, info, handler)
+	observe(info.FullMethod, start, err)
+	return err
+}
+
+func observe(fullMethod string, start time.Time, err error) {
+	method := util.MethodName(fullMethod)
+	grpcReqs.WithLabelValues(status.Code(err).String(), method).Inc()
+	grpcLatency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,31 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bmizerany/assert"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryMetricsInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/hrapp/getEmployee"}
+	notFound := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "employee 1000 not found")
+	}
+	found := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+
+	_, err := unaryMetricsInterceptor(context.Background(), nil, info, notFound)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	resp, err := unaryMetricsInterceptor(context.Background(), "req", info, found)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "req", resp)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcReqs.WithLabelValues("NotFound", "getemployee")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcReqs.WithLabelValues("OK", "getemployee")))
+}
@@ -2,15 +2,24 @@ package hrapp
 
 import (
 	"context"
+	"encoding/base64"
+	"os"
+	"time"
 	c "github.com/nilangshah/hrapp/cassandra"
 	"github.com/pkg/errors"
-	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-var (
-	grpcReqs *prometheus.CounterVec
+const (
+	//Most ids accepted by a single GetEmployees call
+	MAXBATCHSIZE = 1000
+	//Page size of ListEmployees when the request sets none
+	DEFAULTPAGESIZE = 100
+	//Larger ListEmployees page sizes are capped to this
+	MAXPAGESIZE = 1000
 )
 
 type ServiceImpl struct {
@@ -21,7 +30,44 @@ type ServiceImpl struct {
 }
 
 type ServiceImplConfig struct {
-	DBConfig *c.CassandraConfig
+	//Store names the EmployeeStore backend, see RegisterStore
+	Store    string             `config:"store"`
+	DBConfig *c.CassandraConfig `config:"cassandra"`
+	//SeedFile is a JSON array of employees loaded by the memory store, and by the file store when it is empty
+	SeedFile string `config:"seed-file"`
+	//DataDir holds the snapshot and log of the file store
+	DataDir string `config:"data-dir"`
+	//SnapshotEvery is the number of file store writes between snapshots
+	SnapshotEvery int `config:"snapshot-every"`
+	//CacheSize is the number of employees cached in front of the store, 0 disables the cache
+	CacheSize int `config:"cache-size"`
+	//CacheTTL is how long a cached employee is served before it is read again
+	CacheTTL time.Duration `config:"cache-ttl"`
+	//SearchRefresh is how often the cassandra store rebuilds its search index, SEARCHREFRESH when 0
+	SearchRefresh time.Duration `config:"search-refresh"`
+}
+
+//Validate checks that the store is registered and that sizes and durations are usable
+func (config *ServiceImplConfig) Validate() error {
+	if _, ok := storeFactories[config.Store]; !ok && config.Store != "" {
+		return errors.Errorf("unknown store %q, registered stores are %v", config.Store, StoreNames())
+	}
+	switch {
+	case config.SnapshotEvery < 0:
+		return errors.Errorf("snapshot-every must not be negative, got %d", config.SnapshotEvery)
+	case config.CacheSize < 0:
+		return errors.Errorf("cache-size must not be negative, got %d", config.CacheSize)
+	case config.CacheSize > 0 && config.CacheTTL <= 0:
+		return errors.Errorf("cache-ttl must be positive when the cache is enabled, got %s", config.CacheTTL)
+	case config.SearchRefresh < 0:
+		return errors.Errorf("search-refresh must not be negative, got %s", config.SearchRefresh)
+	}
+	if config.SeedFile != "" {
+		if _, err := os.Stat(config.SeedFile); err != nil {
+			return errors.Wrap(err, "seed-file")
+		}
+	}
+	return nil
 }
 
 func NewServiceImpl(config *ServiceImplConfig) *ServiceImpl {
@@ -37,19 +83,11 @@ func (s *ServiceImpl) ServiceDesc() *grpc.ServiceDesc {
 func (s *ServiceImpl) Init(logger *zap.Logger) error {
 	s.logger = logger
 	s.logger.Info("Initializing serviceImpl")
-	empStore, err := EmployeeStoreInit(s.logger, s.Config.DBConfig)
+	empStore, err := NewEmployeeStore(s.logger, s.Config)
 	if err != nil {
 		return errors.Wrap(err, "EmployeeDB initialization failed")
 	}
 	s.empStore = empStore
-	grpcReqs = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "grpc_requests_total",
-			Help: "How many gRPC requests processed, partitioned by status code and HTTP method.",
-		},
-		[]string{"code", "method"},
-	)
-	prometheus.MustRegister(grpcReqs)
 	return nil
 }
 
@@ -64,10 +102,222 @@ func (s *ServiceImpl) ShutDown() {
 	s.empStore.Close()
 }
 
+//HealthCheck fails when the employee store can't serve requests, it drives the gRPC health service
+func (s *ServiceImpl) HealthCheck() error {
+	if s.empStore == nil {
+		return errors.New("employee store is not initialized")
+	}
+	if h, ok := s.empStore.(healthChecker); ok {
+		return h.healthCheck()
+	}
+	return nil
+}
+
+//PrepareReload checks the settings of a newly loaded configuration that can change while serving, the
+//cache TTL and the cassandra consistency, without applying them. The returned function applies them and
+//logs the other changed settings, they take effect after a restart
+func (s *ServiceImpl) PrepareReload(config *ServiceImplConfig) (func(), error) {
+	apply := func() {}
+	if r, ok := s.empStore.(reloader); ok {
+		var err error
+		if apply, err = r.prepareReload(config); err != nil {
+			return nil, err
+		}
+	}
+	return func() {
+		s.applyReload(config)
+		apply()
+	}, nil
+}
+
+//applyReload keeps the reloaded settings in s.Config and logs the changed settings needing a restart
+func (s *ServiceImpl) applyReload(config *ServiceImplConfig) {
+	old := s.Config
+	oldDB, newDB := old.DBConfig, config.DBConfig
+	if oldDB == nil {
+		oldDB = &c.CassandraConfig{}
+	}
+	if newDB == nil {
+		newDB = &c.CassandraConfig{}
+	}
+	settings := []struct {
+		key     string
+		changed bool
+	}{
+		{"store", config.Store != old.Store},
+		{"cassandra.cluster_hosts", newDB.ClusterHosts != oldDB.ClusterHosts},
+		{"cassandra.keyspace", newDB.Keyspace != oldDB.Keyspace},
+		{"seed-file", config.SeedFile != old.SeedFile},
+		{"data-dir", config.DataDir != old.DataDir},
+		{"snapshot-every", config.SnapshotEvery != old.SnapshotEvery},
+		{"cache-size", config.CacheSize != old.CacheSize},
+		{"search-refresh", config.SearchRefresh != old.SearchRefresh},
+	}
+	for _, setting := range settings {
+		if setting.changed {
+			s.logger.Warn("Reload: Setting changed, restart the service to apply it", zap.String("key", setting.key))
+		}
+	}
+	old.CacheTTL = config.CacheTTL
+	if old.DBConfig != nil {
+		old.DBConfig.Consistency = newDB.Consistency
+	}
+}
+
 // Function to implement Business API
 func (s *ServiceImpl) GetEmployee(ctx context.Context, id *EmployeeId) (*Employee, error) {
 	s.logger.Debug("gRPC: GetEmployee called", zap.Int64("empId", id.Id))
-	grpcReqs.WithLabelValues("200", "getemployee").Inc()
-	return s.empStore.GetEmployee(id)
+	emp, err := s.empStore.GetEmployee(id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return emp, nil
+
+}
+
+//GetEmployees fetches up to MAXBATCHSIZE employees in one call and reports the ids that don't exist
+func (s *ServiceImpl) GetEmployees(ctx context.Context, req *EmployeeIds) (*EmployeesResponse, error) {
+	s.logger.Debug("gRPC: GetEmployees called", zap.Int("count", len(req.Ids)))
+	if len(req.Ids) > MAXBATCHSIZE {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d ids per request, got %d", MAXBATCHSIZE, len(req.Ids))
+	}
+	employees, missing, err := s.empStore.GetEmployees(req.Ids)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &EmployeesResponse{Employees: employees, MissingIds: missing}, nil
+}
+
+//ListEmployees returns one page of employees matching the request filters, next_page_token fetches the next one
+func (s *ServiceImpl) ListEmployees(ctx context.Context, req *ListEmployeesRequest) (*ListEmployeesResponse, error) {
+	s.logger.Debug("gRPC: ListEmployees called", zap.Int32("pageSize", req.PageSize), zap.String("title", req.Title), zap.String("namePrefix", req.NamePrefix))
+	pageSize, pageState, err := pageRequest(req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	employees, next, err := s.empStore.ListEmployees(&EmployeeFilter{Title: req.Title, NamePrefix: req.NamePrefix}, pageSize, pageState)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ListEmployeesResponse{Employees: employees, NextPageToken: base64.RawURLEncoding.EncodeToString(next)}, nil
+}
+
+//SearchEmployees returns one page of employees whose name contains req.Name and whose title is req.Title,
+//best name matches first
+func (s *ServiceImpl) SearchEmployees(ctx context.Context, req *SearchEmployeesRequest) (*SearchEmployeesResponse, error) {
+	s.logger.Debug("gRPC: SearchEmployees called", zap.String("name", req.Name), zap.String("title", req.Title))
+	if req.Name == "" && req.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "name or title is required")
+	}
+	pageSize, pageState, err := pageRequest(req.PageSize, req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	employees, next, err := s.empStore.SearchEmployees(&SearchQuery{Name: req.Name, Title: req.Title}, pageSize, pageState)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &SearchEmployeesResponse{Employees: employees, NextPageToken: base64.RawURLEncoding.EncodeToString(next)}, nil
+}
 
+//pageRequest validates the page size and decodes the page token of a paginated request
+func pageRequest(size int32, token string) (int, []byte, error) {
+	pageSize := int(size)
+	switch {
+	case pageSize < 0:
+		return 0, nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative, got %d", pageSize)
+	case pageSize == 0:
+		pageSize = DEFAULTPAGESIZE
+	case pageSize > MAXPAGESIZE:
+		pageSize = MAXPAGESIZE
+	}
+	pageState, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, nil, status.Error(codes.InvalidArgument, "malformed page token")
+	}
+	return pageSize, pageState, nil
+}
+
+func (s *ServiceImpl) CreateEmployee(ctx context.Context, req *CreateEmployeeRequest) (*Employee, error) {
+	s.logger.Debug("gRPC: CreateEmployee called", zap.Int64("managerId", req.ManagerId))
+	emp := req.Employee
+	if err := validateEmployee(emp); err != nil {
+		return nil, err
+	}
+	if req.ManagerId < 0 || req.ManagerId == emp.Id {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid manager id %d", req.ManagerId)
+	}
+	created := &Employee{Id: emp.Id, Name: emp.Name, Title: emp.Title, Reports: []int64{}, ManagerId: req.ManagerId}
+	if err := s.empStore.CreateEmployee(created, req.ManagerId); err != nil {
+		return nil, toStatusError(err)
+	}
+	return created, nil
+}
+
+func (s *ServiceImpl) UpdateEmployee(ctx context.Context, emp *Employee) (*Employee, error) {
+	s.logger.Debug("gRPC: UpdateEmployee called", zap.Int64("empId", emp.Id))
+	if err := validateEmployee(emp); err != nil {
+		return nil, err
+	}
+	if err := s.empStore.UpdateEmployee(emp); err != nil {
+		return nil, toStatusError(err)
+	}
+	updated, err := s.empStore.GetEmployee(&EmployeeId{Id: emp.Id})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return updated, nil
+}
+
+func (s *ServiceImpl) DeleteEmployee(ctx context.Context, id *EmployeeId) (*Employee, error) {
+	s.logger.Debug("gRPC: DeleteEmployee called", zap.Int64("empId", id.Id))
+	emp, err := s.empStore.DeleteEmployee(id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return emp, nil
+}
+
+//MoveEmployee puts an employee under a new manager, with its reports or handing them to its old manager
+func (s *ServiceImpl) MoveEmployee(ctx context.Context, req *MoveEmployeeRequest) (*Employee, error) {
+	s.logger.Debug("gRPC: MoveEmployee called", zap.Int64("empId", req.Id), zap.Int64("newManagerId", req.NewManagerId), zap.Bool("moveReportsToo", req.MoveReportsToo))
+	emp, err := s.empStore.MoveEmployee(req.Id, req.NewManagerId, req.MoveReportsToo)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return emp, nil
+}
+
+//validateEmployee checks the fields clients must supply on writes
+func validateEmployee(emp *Employee) error {
+	if emp == nil {
+		return status.Error(codes.InvalidArgument, "employee is required")
+	}
+	if emp.Id <= 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid employee id %d", emp.Id)
+	}
+	if emp.Name == "" {
+		return status.Error(codes.InvalidArgument, "employee name is required")
+	}
+	return nil
+}
+
+//toStatusError maps EmployeeStore errors to gRPC status errors
+func toStatusError(err error) error {
+	switch errors.Cause(err) {
+	case ErrEmployeeNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case ErrEmployeeExists:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case ErrEmployeeHasReports, ErrHierarchyCycle:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case ErrStoreUnavailable:
+		return status.Error(codes.Unavailable, err.Error())
+	case ErrStoreTimeout:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case ErrInvalidPageToken, ErrInvalidImport, ErrInvalidMove:
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
 }
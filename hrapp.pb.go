@@ -1,25 +1,14 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // source: hrapp.proto
 
-/*
-Package hrapp is a generated protocol buffer package.
-
-It is generated from these files:
-	hrapp.proto
-
-It has these top-level messages:
-	EmployeeId
-	Employee
-*/
 package hrapp
 
-import proto "github.com/golang/protobuf/proto"
-import fmt "fmt"
-import math "math"
-
 import (
-	context "golang.org/x/net/context"
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
 	grpc "google.golang.org/grpc"
+	math "math"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -31,16 +20,39 @@ var _ = math.Inf
 // is compatible with the proto package it is being compiled against.
 // A compilation error at this line likely means your copy of the
 // proto package needs to be updated.
-const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type EmployeeId struct {
-	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Id                   int64    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EmployeeId) Reset()         { *m = EmployeeId{} }
+func (m *EmployeeId) String() string { return proto.CompactTextString(m) }
+func (*EmployeeId) ProtoMessage()    {}
+func (*EmployeeId) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{0}
+}
+
+func (m *EmployeeId) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EmployeeId.Unmarshal(m, b)
+}
+func (m *EmployeeId) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EmployeeId.Marshal(b, m, deterministic)
+}
+func (m *EmployeeId) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EmployeeId.Merge(m, src)
+}
+func (m *EmployeeId) XXX_Size() int {
+	return xxx_messageInfo_EmployeeId.Size(m)
+}
+func (m *EmployeeId) XXX_DiscardUnknown() {
+	xxx_messageInfo_EmployeeId.DiscardUnknown(m)
 }
 
-func (m *EmployeeId) Reset()                    { *m = EmployeeId{} }
-func (m *EmployeeId) String() string            { return proto.CompactTextString(m) }
-func (*EmployeeId) ProtoMessage()               {}
-func (*EmployeeId) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+var xxx_messageInfo_EmployeeId proto.InternalMessageInfo
 
 func (m *EmployeeId) GetId() int64 {
 	if m != nil {
@@ -49,17 +61,81 @@ func (m *EmployeeId) GetId() int64 {
 	return 0
 }
 
+type EmployeeIds struct {
+	Ids                  []int64  `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EmployeeIds) Reset()         { *m = EmployeeIds{} }
+func (m *EmployeeIds) String() string { return proto.CompactTextString(m) }
+func (*EmployeeIds) ProtoMessage()    {}
+func (*EmployeeIds) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{1}
+}
+
+func (m *EmployeeIds) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EmployeeIds.Unmarshal(m, b)
+}
+func (m *EmployeeIds) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EmployeeIds.Marshal(b, m, deterministic)
+}
+func (m *EmployeeIds) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EmployeeIds.Merge(m, src)
+}
+func (m *EmployeeIds) XXX_Size() int {
+	return xxx_messageInfo_EmployeeIds.Size(m)
+}
+func (m *EmployeeIds) XXX_DiscardUnknown() {
+	xxx_messageInfo_EmployeeIds.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EmployeeIds proto.InternalMessageInfo
+
+func (m *EmployeeIds) GetIds() []int64 {
+	if m != nil {
+		return m.Ids
+	}
+	return nil
+}
+
 type Employee struct {
-	Id      int64   `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	Name    string  `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
-	Title   string  `protobuf:"bytes,3,opt,name=title" json:"title,omitempty"`
-	Reports []int64 `protobuf:"varint,4,rep,packed,name=reports" json:"reports,omitempty"`
+	Id      int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Title   string  `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Reports []int64 `protobuf:"varint,4,rep,packed,name=reports,proto3" json:"reports,omitempty"`
+	// 0 for employees without a manager
+	ManagerId            int64    `protobuf:"varint,5,opt,name=manager_id,json=managerId,proto3" json:"manager_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Employee) Reset()         { *m = Employee{} }
+func (m *Employee) String() string { return proto.CompactTextString(m) }
+func (*Employee) ProtoMessage()    {}
+func (*Employee) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{2}
+}
+
+func (m *Employee) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Employee.Unmarshal(m, b)
+}
+func (m *Employee) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Employee.Marshal(b, m, deterministic)
+}
+func (m *Employee) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Employee.Merge(m, src)
+}
+func (m *Employee) XXX_Size() int {
+	return xxx_messageInfo_Employee.Size(m)
+}
+func (m *Employee) XXX_DiscardUnknown() {
+	xxx_messageInfo_Employee.DiscardUnknown(m)
 }
 
-func (m *Employee) Reset()                    { *m = Employee{} }
-func (m *Employee) String() string            { return proto.CompactTextString(m) }
-func (*Employee) ProtoMessage()               {}
-func (*Employee) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+var xxx_messageInfo_Employee proto.InternalMessageInfo
 
 func (m *Employee) GetId() int64 {
 	if m != nil {
@@ -89,95 +165,1492 @@ func (m *Employee) GetReports() []int64 {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*EmployeeId)(nil), "EmployeeId")
-	proto.RegisterType((*Employee)(nil), "Employee")
+func (m *Employee) GetManagerId() int64 {
+	if m != nil {
+		return m.ManagerId
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+type EmployeesResponse struct {
+	// in request order, duplicates removed
+	Employees            []*Employee `protobuf:"bytes,1,rep,name=employees,proto3" json:"employees,omitempty"`
+	MissingIds           []int64     `protobuf:"varint,2,rep,packed,name=missing_ids,json=missingIds,proto3" json:"missing_ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *EmployeesResponse) Reset()         { *m = EmployeesResponse{} }
+func (m *EmployeesResponse) String() string { return proto.CompactTextString(m) }
+func (*EmployeesResponse) ProtoMessage()    {}
+func (*EmployeesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{3}
+}
 
-// Client API for Hrapp service
+func (m *EmployeesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EmployeesResponse.Unmarshal(m, b)
+}
+func (m *EmployeesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EmployeesResponse.Marshal(b, m, deterministic)
+}
+func (m *EmployeesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EmployeesResponse.Merge(m, src)
+}
+func (m *EmployeesResponse) XXX_Size() int {
+	return xxx_messageInfo_EmployeesResponse.Size(m)
+}
+func (m *EmployeesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EmployeesResponse.DiscardUnknown(m)
+}
 
-type HrappClient interface {
-	GetEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error)
+var xxx_messageInfo_EmployeesResponse proto.InternalMessageInfo
+
+func (m *EmployeesResponse) GetEmployees() []*Employee {
+	if m != nil {
+		return m.Employees
+	}
+	return nil
 }
 
-type hrappClient struct {
-	cc *grpc.ClientConn
+func (m *EmployeesResponse) GetMissingIds() []int64 {
+	if m != nil {
+		return m.MissingIds
+	}
+	return nil
 }
 
-func NewHrappClient(cc *grpc.ClientConn) HrappClient {
-	return &hrappClient{cc}
+type CreateEmployeeRequest struct {
+	Employee *Employee `protobuf:"bytes,1,opt,name=employee,proto3" json:"employee,omitempty"`
+	// manager_id 0 creates a top level employee
+	ManagerId            int64    `protobuf:"varint,2,opt,name=manager_id,json=managerId,proto3" json:"manager_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (c *hrappClient) GetEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error) {
-	out := new(Employee)
-	err := grpc.Invoke(ctx, "/hrapp/getEmployee", in, out, c.cc, opts...)
-	if err != nil {
-		return nil, err
+func (m *CreateEmployeeRequest) Reset()         { *m = CreateEmployeeRequest{} }
+func (m *CreateEmployeeRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateEmployeeRequest) ProtoMessage()    {}
+func (*CreateEmployeeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{4}
+}
+
+func (m *CreateEmployeeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateEmployeeRequest.Unmarshal(m, b)
+}
+func (m *CreateEmployeeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateEmployeeRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateEmployeeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateEmployeeRequest.Merge(m, src)
+}
+func (m *CreateEmployeeRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateEmployeeRequest.Size(m)
+}
+func (m *CreateEmployeeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateEmployeeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateEmployeeRequest proto.InternalMessageInfo
+
+func (m *CreateEmployeeRequest) GetEmployee() *Employee {
+	if m != nil {
+		return m.Employee
 	}
-	return out, nil
+	return nil
 }
 
-// Server API for Hrapp service
+func (m *CreateEmployeeRequest) GetManagerId() int64 {
+	if m != nil {
+		return m.ManagerId
+	}
+	return 0
+}
 
-type HrappServer interface {
-	GetEmployee(context.Context, *EmployeeId) (*Employee, error)
+type MoveEmployeeRequest struct {
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// 0 makes the employee a top level employee
+	NewManagerId int64 `protobuf:"varint,2,opt,name=new_manager_id,json=newManagerId,proto3" json:"new_manager_id,omitempty"`
+	// false hands the employee's reports to its old manager
+	MoveReportsToo       bool     `protobuf:"varint,3,opt,name=move_reports_too,json=moveReportsToo,proto3" json:"move_reports_too,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func RegisterHrappServer(s *grpc.Server, srv HrappServer) {
-	s.RegisterService(&_Hrapp_serviceDesc, srv)
+func (m *MoveEmployeeRequest) Reset()         { *m = MoveEmployeeRequest{} }
+func (m *MoveEmployeeRequest) String() string { return proto.CompactTextString(m) }
+func (*MoveEmployeeRequest) ProtoMessage()    {}
+func (*MoveEmployeeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{5}
 }
 
-func _Hrapp_GetEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(EmployeeId)
-	if err := dec(in); err != nil {
-		return nil, err
+func (m *MoveEmployeeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MoveEmployeeRequest.Unmarshal(m, b)
+}
+func (m *MoveEmployeeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MoveEmployeeRequest.Marshal(b, m, deterministic)
+}
+func (m *MoveEmployeeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MoveEmployeeRequest.Merge(m, src)
+}
+func (m *MoveEmployeeRequest) XXX_Size() int {
+	return xxx_messageInfo_MoveEmployeeRequest.Size(m)
+}
+func (m *MoveEmployeeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MoveEmployeeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MoveEmployeeRequest proto.InternalMessageInfo
+
+func (m *MoveEmployeeRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
 	}
-	if interceptor == nil {
-		return srv.(HrappServer).GetEmployee(ctx, in)
+	return 0
+}
+
+func (m *MoveEmployeeRequest) GetNewManagerId() int64 {
+	if m != nil {
+		return m.NewManagerId
 	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/hrapp/GetEmployee",
+	return 0
+}
+
+func (m *MoveEmployeeRequest) GetMoveReportsToo() bool {
+	if m != nil {
+		return m.MoveReportsToo
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(HrappServer).GetEmployee(ctx, req.(*EmployeeId))
+	return false
+}
+
+type ImportEmployeesRequest struct {
+	// only id, name, title and manager_id are read, reports follow from manager_id.
+	// manager_id -1 keeps the stored manager of an employee
+	Employees []*Employee `protobuf:"bytes,1,rep,name=employees,proto3" json:"employees,omitempty"`
+	// validate and return the changes without writing them, set on any message of the stream
+	DryRun               bool     `protobuf:"varint,2,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImportEmployeesRequest) Reset()         { *m = ImportEmployeesRequest{} }
+func (m *ImportEmployeesRequest) String() string { return proto.CompactTextString(m) }
+func (*ImportEmployeesRequest) ProtoMessage()    {}
+func (*ImportEmployeesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{6}
+}
+
+func (m *ImportEmployeesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportEmployeesRequest.Unmarshal(m, b)
+}
+func (m *ImportEmployeesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportEmployeesRequest.Marshal(b, m, deterministic)
+}
+func (m *ImportEmployeesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportEmployeesRequest.Merge(m, src)
+}
+func (m *ImportEmployeesRequest) XXX_Size() int {
+	return xxx_messageInfo_ImportEmployeesRequest.Size(m)
+}
+func (m *ImportEmployeesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportEmployeesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportEmployeesRequest proto.InternalMessageInfo
+
+func (m *ImportEmployeesRequest) GetEmployees() []*Employee {
+	if m != nil {
+		return m.Employees
 	}
-	return interceptor(ctx, in, info, handler)
+	return nil
 }
 
-var _Hrapp_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "hrapp",
-	HandlerType: (*HrappServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "getEmployee",
-			Handler:    _Hrapp_GetEmployee_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "hrapp.proto",
+func (m *ImportEmployeesRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type EmployeeChange struct {
+	Before               *Employee `protobuf:"bytes,1,opt,name=before,proto3" json:"before,omitempty"`
+	After                *Employee `protobuf:"bytes,2,opt,name=after,proto3" json:"after,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *EmployeeChange) Reset()         { *m = EmployeeChange{} }
+func (m *EmployeeChange) String() string { return proto.CompactTextString(m) }
+func (*EmployeeChange) ProtoMessage()    {}
+func (*EmployeeChange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{7}
+}
+
+func (m *EmployeeChange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EmployeeChange.Unmarshal(m, b)
+}
+func (m *EmployeeChange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EmployeeChange.Marshal(b, m, deterministic)
+}
+func (m *EmployeeChange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EmployeeChange.Merge(m, src)
+}
+func (m *EmployeeChange) XXX_Size() int {
+	return xxx_messageInfo_EmployeeChange.Size(m)
+}
+func (m *EmployeeChange) XXX_DiscardUnknown() {
+	xxx_messageInfo_EmployeeChange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EmployeeChange proto.InternalMessageInfo
+
+func (m *EmployeeChange) GetBefore() *Employee {
+	if m != nil {
+		return m.Before
+	}
+	return nil
+}
+
+func (m *EmployeeChange) GetAfter() *Employee {
+	if m != nil {
+		return m.After
+	}
+	return nil
+}
+
+type ImportEmployeesResponse struct {
+	Created []*Employee `protobuf:"bytes,1,rep,name=created,proto3" json:"created,omitempty"`
+	// employees imported with new values and managers whose reports changed
+	Updated              []*EmployeeChange `protobuf:"bytes,2,rep,name=updated,proto3" json:"updated,omitempty"`
+	DryRun               bool              `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ImportEmployeesResponse) Reset()         { *m = ImportEmployeesResponse{} }
+func (m *ImportEmployeesResponse) String() string { return proto.CompactTextString(m) }
+func (*ImportEmployeesResponse) ProtoMessage()    {}
+func (*ImportEmployeesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{8}
+}
+
+func (m *ImportEmployeesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportEmployeesResponse.Unmarshal(m, b)
+}
+func (m *ImportEmployeesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportEmployeesResponse.Marshal(b, m, deterministic)
+}
+func (m *ImportEmployeesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportEmployeesResponse.Merge(m, src)
+}
+func (m *ImportEmployeesResponse) XXX_Size() int {
+	return xxx_messageInfo_ImportEmployeesResponse.Size(m)
+}
+func (m *ImportEmployeesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportEmployeesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportEmployeesResponse proto.InternalMessageInfo
+
+func (m *ImportEmployeesResponse) GetCreated() []*Employee {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *ImportEmployeesResponse) GetUpdated() []*EmployeeChange {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+func (m *ImportEmployeesResponse) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type ReportingTreeRequest struct {
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// levels below id to include, 0 means no limit
+	MaxDepth             int32    `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReportingTreeRequest) Reset()         { *m = ReportingTreeRequest{} }
+func (m *ReportingTreeRequest) String() string { return proto.CompactTextString(m) }
+func (*ReportingTreeRequest) ProtoMessage()    {}
+func (*ReportingTreeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{9}
+}
+
+func (m *ReportingTreeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReportingTreeRequest.Unmarshal(m, b)
+}
+func (m *ReportingTreeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReportingTreeRequest.Marshal(b, m, deterministic)
+}
+func (m *ReportingTreeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReportingTreeRequest.Merge(m, src)
+}
+func (m *ReportingTreeRequest) XXX_Size() int {
+	return xxx_messageInfo_ReportingTreeRequest.Size(m)
+}
+func (m *ReportingTreeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReportingTreeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReportingTreeRequest proto.InternalMessageInfo
+
+func (m *ReportingTreeRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ReportingTreeRequest) GetMaxDepth() int32 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+type ReportingTree struct {
+	Id                   int64            `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                 string           `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Title                string           `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	Reports              []*ReportingTree `protobuf:"bytes,4,rep,name=reports,proto3" json:"reports,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *ReportingTree) Reset()         { *m = ReportingTree{} }
+func (m *ReportingTree) String() string { return proto.CompactTextString(m) }
+func (*ReportingTree) ProtoMessage()    {}
+func (*ReportingTree) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{10}
+}
+
+func (m *ReportingTree) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReportingTree.Unmarshal(m, b)
+}
+func (m *ReportingTree) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReportingTree.Marshal(b, m, deterministic)
+}
+func (m *ReportingTree) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReportingTree.Merge(m, src)
+}
+func (m *ReportingTree) XXX_Size() int {
+	return xxx_messageInfo_ReportingTree.Size(m)
+}
+func (m *ReportingTree) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReportingTree.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReportingTree proto.InternalMessageInfo
+
+func (m *ReportingTree) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ReportingTree) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ReportingTree) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *ReportingTree) GetReports() []*ReportingTree {
+	if m != nil {
+		return m.Reports
+	}
+	return nil
+}
+
+type SubtreeNode struct {
+	Employee *Employee `protobuf:"bytes,1,opt,name=employee,proto3" json:"employee,omitempty"`
+	// 0 for the requested root
+	ParentId             int64    `protobuf:"varint,2,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Depth                int32    `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("hrapp.proto", fileDescriptor0) }
-
-var fileDescriptor0 = []byte{
-	// 155 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0xce, 0x28, 0x4a, 0x2c,
-	0x28, 0xd0, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x57, 0x92, 0xe1, 0xe2, 0x72, 0xcd, 0x2d, 0xc8, 0xc9,
-	0xaf, 0x4c, 0x4d, 0xf5, 0x4c, 0x11, 0xe2, 0xe3, 0x62, 0xca, 0x4c, 0x91, 0x60, 0x54, 0x60, 0xd4,
-	0x60, 0x0e, 0x62, 0xca, 0x4c, 0x51, 0x8a, 0xe3, 0xe2, 0x80, 0xc9, 0xa2, 0xcb, 0x09, 0x09, 0x71,
-	0xb1, 0xe4, 0x25, 0xe6, 0xa6, 0x4a, 0x30, 0x29, 0x30, 0x6a, 0x70, 0x06, 0x81, 0xd9, 0x42, 0x22,
-	0x5c, 0xac, 0x25, 0x99, 0x25, 0x39, 0xa9, 0x12, 0xcc, 0x60, 0x41, 0x08, 0x47, 0x48, 0x82, 0x8b,
-	0xbd, 0x28, 0xb5, 0x20, 0xbf, 0xa8, 0xa4, 0x58, 0x82, 0x45, 0x81, 0x59, 0x83, 0x39, 0x08, 0xc6,
-	0x35, 0xd2, 0xe3, 0x62, 0x05, 0x3b, 0x46, 0x48, 0x95, 0x8b, 0x3b, 0x3d, 0xb5, 0x04, 0x6e, 0x17,
-	0xb7, 0x1e, 0xc2, 0x51, 0x52, 0x9c, 0x70, 0x4e, 0x12, 0x1b, 0xd8, 0xd1, 0xc6, 0x80, 0x00, 0x00,
-	0x00, 0xff, 0xff, 0xfd, 0x26, 0x9a, 0x55, 0xc3, 0x00, 0x00, 0x00,
+func (m *SubtreeNode) Reset()         { *m = SubtreeNode{} }
+func (m *SubtreeNode) String() string { return proto.CompactTextString(m) }
+func (*SubtreeNode) ProtoMessage()    {}
+func (*SubtreeNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{11}
+}
+
+func (m *SubtreeNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubtreeNode.Unmarshal(m, b)
+}
+func (m *SubtreeNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubtreeNode.Marshal(b, m, deterministic)
+}
+func (m *SubtreeNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubtreeNode.Merge(m, src)
+}
+func (m *SubtreeNode) XXX_Size() int {
+	return xxx_messageInfo_SubtreeNode.Size(m)
+}
+func (m *SubtreeNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubtreeNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubtreeNode proto.InternalMessageInfo
+
+func (m *SubtreeNode) GetEmployee() *Employee {
+	if m != nil {
+		return m.Employee
+	}
+	return nil
+}
+
+func (m *SubtreeNode) GetParentId() int64 {
+	if m != nil {
+		return m.ParentId
+	}
+	return 0
+}
+
+func (m *SubtreeNode) GetDepth() int32 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+type ExportOrgRequest struct {
+	Id int64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// levels below id to include, 0 means no limit
+	MaxDepth int32 `protobuf:"varint,2,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	// json, yaml, csv, dot, mermaid or text, json when empty
+	Format               string   `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportOrgRequest) Reset()         { *m = ExportOrgRequest{} }
+func (m *ExportOrgRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportOrgRequest) ProtoMessage()    {}
+func (*ExportOrgRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{12}
+}
+
+func (m *ExportOrgRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportOrgRequest.Unmarshal(m, b)
+}
+func (m *ExportOrgRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportOrgRequest.Marshal(b, m, deterministic)
+}
+func (m *ExportOrgRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportOrgRequest.Merge(m, src)
+}
+func (m *ExportOrgRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportOrgRequest.Size(m)
+}
+func (m *ExportOrgRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportOrgRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportOrgRequest proto.InternalMessageInfo
+
+func (m *ExportOrgRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *ExportOrgRequest) GetMaxDepth() int32 {
+	if m != nil {
+		return m.MaxDepth
+	}
+	return 0
+}
+
+func (m *ExportOrgRequest) GetFormat() string {
+	if m != nil {
+		return m.Format
+	}
+	return ""
+}
+
+type ExportChunk struct {
+	// the rendered hierarchy is the concatenation of all chunks
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportChunk) Reset()         { *m = ExportChunk{} }
+func (m *ExportChunk) String() string { return proto.CompactTextString(m) }
+func (*ExportChunk) ProtoMessage()    {}
+func (*ExportChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{13}
+}
+
+func (m *ExportChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportChunk.Unmarshal(m, b)
+}
+func (m *ExportChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportChunk.Marshal(b, m, deterministic)
+}
+func (m *ExportChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportChunk.Merge(m, src)
+}
+func (m *ExportChunk) XXX_Size() int {
+	return xxx_messageInfo_ExportChunk.Size(m)
+}
+func (m *ExportChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportChunk proto.InternalMessageInfo
+
+func (m *ExportChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type ManagerChain struct {
+	// direct manager first, root last
+	Managers             []*Employee `protobuf:"bytes,1,rep,name=managers,proto3" json:"managers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ManagerChain) Reset()         { *m = ManagerChain{} }
+func (m *ManagerChain) String() string { return proto.CompactTextString(m) }
+func (*ManagerChain) ProtoMessage()    {}
+func (*ManagerChain) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{14}
+}
+
+func (m *ManagerChain) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ManagerChain.Unmarshal(m, b)
+}
+func (m *ManagerChain) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ManagerChain.Marshal(b, m, deterministic)
+}
+func (m *ManagerChain) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ManagerChain.Merge(m, src)
+}
+func (m *ManagerChain) XXX_Size() int {
+	return xxx_messageInfo_ManagerChain.Size(m)
+}
+func (m *ManagerChain) XXX_DiscardUnknown() {
+	xxx_messageInfo_ManagerChain.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ManagerChain proto.InternalMessageInfo
+
+func (m *ManagerChain) GetManagers() []*Employee {
+	if m != nil {
+		return m.Managers
+	}
+	return nil
+}
+
+type ListEmployeesRequest struct {
+	// 0 means the default of 100, larger sizes are capped at 1000
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response, empty for the first page
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// only employees with this title, case insensitive
+	Title string `protobuf:"bytes,3,opt,name=title,proto3" json:"title,omitempty"`
+	// only employees whose name starts with this prefix, case insensitive
+	NamePrefix           string   `protobuf:"bytes,4,opt,name=name_prefix,json=namePrefix,proto3" json:"name_prefix,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListEmployeesRequest) Reset()         { *m = ListEmployeesRequest{} }
+func (m *ListEmployeesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListEmployeesRequest) ProtoMessage()    {}
+func (*ListEmployeesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{15}
+}
+
+func (m *ListEmployeesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListEmployeesRequest.Unmarshal(m, b)
+}
+func (m *ListEmployeesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListEmployeesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListEmployeesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListEmployeesRequest.Merge(m, src)
+}
+func (m *ListEmployeesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListEmployeesRequest.Size(m)
+}
+func (m *ListEmployeesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListEmployeesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListEmployeesRequest proto.InternalMessageInfo
+
+func (m *ListEmployeesRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListEmployeesRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *ListEmployeesRequest) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *ListEmployeesRequest) GetNamePrefix() string {
+	if m != nil {
+		return m.NamePrefix
+	}
+	return ""
+}
+
+type ListEmployeesResponse struct {
+	// with filters a page can hold fewer than page_size employees before the last one
+	Employees []*Employee `protobuf:"bytes,1,rep,name=employees,proto3" json:"employees,omitempty"`
+	// empty on the last page
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListEmployeesResponse) Reset()         { *m = ListEmployeesResponse{} }
+func (m *ListEmployeesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListEmployeesResponse) ProtoMessage()    {}
+func (*ListEmployeesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{16}
+}
+
+func (m *ListEmployeesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListEmployeesResponse.Unmarshal(m, b)
+}
+func (m *ListEmployeesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListEmployeesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListEmployeesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListEmployeesResponse.Merge(m, src)
+}
+func (m *ListEmployeesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListEmployeesResponse.Size(m)
+}
+func (m *ListEmployeesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListEmployeesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListEmployeesResponse proto.InternalMessageInfo
+
+func (m *ListEmployeesResponse) GetEmployees() []*Employee {
+	if m != nil {
+		return m.Employees
+	}
+	return nil
+}
+
+func (m *ListEmployeesResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+type SearchEmployeesRequest struct {
+	// matches names starting with or containing it, case insensitive
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// matches the whole title, case insensitive
+	Title string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// 0 means the default of 100, larger sizes are capped at 1000
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response, empty for the first page
+	PageToken            string   `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchEmployeesRequest) Reset()         { *m = SearchEmployeesRequest{} }
+func (m *SearchEmployeesRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchEmployeesRequest) ProtoMessage()    {}
+func (*SearchEmployeesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{17}
+}
+
+func (m *SearchEmployeesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchEmployeesRequest.Unmarshal(m, b)
+}
+func (m *SearchEmployeesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchEmployeesRequest.Marshal(b, m, deterministic)
+}
+func (m *SearchEmployeesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchEmployeesRequest.Merge(m, src)
+}
+func (m *SearchEmployeesRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchEmployeesRequest.Size(m)
+}
+func (m *SearchEmployeesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchEmployeesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchEmployeesRequest proto.InternalMessageInfo
+
+func (m *SearchEmployeesRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *SearchEmployeesRequest) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *SearchEmployeesRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *SearchEmployeesRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+type SearchEmployeesResponse struct {
+	// exact name matches first, then names starting with name, then names containing it
+	Employees []*Employee `protobuf:"bytes,1,rep,name=employees,proto3" json:"employees,omitempty"`
+	// empty on the last page
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchEmployeesResponse) Reset()         { *m = SearchEmployeesResponse{} }
+func (m *SearchEmployeesResponse) String() string { return proto.CompactTextString(m) }
+func (*SearchEmployeesResponse) ProtoMessage()    {}
+func (*SearchEmployeesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8efef3ce07a203b5, []int{18}
+}
+
+func (m *SearchEmployeesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchEmployeesResponse.Unmarshal(m, b)
+}
+func (m *SearchEmployeesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchEmployeesResponse.Marshal(b, m, deterministic)
+}
+func (m *SearchEmployeesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchEmployeesResponse.Merge(m, src)
+}
+func (m *SearchEmployeesResponse) XXX_Size() int {
+	return xxx_messageInfo_SearchEmployeesResponse.Size(m)
+}
+func (m *SearchEmployeesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchEmployeesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchEmployeesResponse proto.InternalMessageInfo
+
+func (m *SearchEmployeesResponse) GetEmployees() []*Employee {
+	if m != nil {
+		return m.Employees
+	}
+	return nil
+}
+
+func (m *SearchEmployeesResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*EmployeeId)(nil), "EmployeeId")
+	proto.RegisterType((*EmployeeIds)(nil), "EmployeeIds")
+	proto.RegisterType((*Employee)(nil), "Employee")
+	proto.RegisterType((*EmployeesResponse)(nil), "EmployeesResponse")
+	proto.RegisterType((*CreateEmployeeRequest)(nil), "CreateEmployeeRequest")
+	proto.RegisterType((*MoveEmployeeRequest)(nil), "MoveEmployeeRequest")
+	proto.RegisterType((*ImportEmployeesRequest)(nil), "ImportEmployeesRequest")
+	proto.RegisterType((*EmployeeChange)(nil), "EmployeeChange")
+	proto.RegisterType((*ImportEmployeesResponse)(nil), "ImportEmployeesResponse")
+	proto.RegisterType((*ReportingTreeRequest)(nil), "ReportingTreeRequest")
+	proto.RegisterType((*ReportingTree)(nil), "ReportingTree")
+	proto.RegisterType((*SubtreeNode)(nil), "SubtreeNode")
+	proto.RegisterType((*ExportOrgRequest)(nil), "ExportOrgRequest")
+	proto.RegisterType((*ExportChunk)(nil), "ExportChunk")
+	proto.RegisterType((*ManagerChain)(nil), "ManagerChain")
+	proto.RegisterType((*ListEmployeesRequest)(nil), "ListEmployeesRequest")
+	proto.RegisterType((*ListEmployeesResponse)(nil), "ListEmployeesResponse")
+	proto.RegisterType((*SearchEmployeesRequest)(nil), "SearchEmployeesRequest")
+	proto.RegisterType((*SearchEmployeesResponse)(nil), "SearchEmployeesResponse")
+}
+
+func init() { proto.RegisterFile("hrapp.proto", fileDescriptor_8efef3ce07a203b5) }
+
+var fileDescriptor_8efef3ce07a203b5 = []byte{
+	// 883 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0xdd, 0x6f, 0x1b, 0x45,
+	0x10, 0xd7, 0xd9, 0x71, 0x62, 0xcf, 0xf9, 0x23, 0x5d, 0x12, 0xfb, 0xe4, 0x82, 0x92, 0x2e, 0xb4,
+	0x98, 0x07, 0x96, 0x28, 0x15, 0x48, 0xbc, 0xf0, 0xe2, 0x16, 0xc9, 0x12, 0x85, 0xea, 0x12, 0x09,
+	0x09, 0xa9, 0x3a, 0x5d, 0xb2, 0x93, 0xf3, 0xd1, 0xdc, 0x07, 0xbb, 0xeb, 0xd6, 0xa9, 0x04, 0x0f,
+	0xbc, 0xf1, 0x1f, 0xf1, 0xe7, 0xa1, 0xdb, 0xbb, 0xb3, 0xef, 0xcb, 0x10, 0x40, 0x7d, 0xbb, 0x9d,
+	0x99, 0x9d, 0xf9, 0xfd, 0x7e, 0xbb, 0x33, 0x7b, 0x60, 0x2e, 0x85, 0x1b, 0xc7, 0x2c, 0x16, 0x91,
+	0x8a, 0xe8, 0x87, 0x00, 0xcf, 0x83, 0xf8, 0x36, 0xba, 0x43, 0x5c, 0x70, 0x32, 0x84, 0x96, 0xcf,
+	0x2d, 0xe3, 0xd4, 0x98, 0xb5, 0xed, 0x96, 0xcf, 0xe9, 0x09, 0x98, 0x5b, 0xaf, 0x24, 0x87, 0xd0,
+	0xf6, 0xb9, 0xb4, 0x8c, 0xd3, 0xf6, 0xac, 0x6d, 0x27, 0x9f, 0xf4, 0x57, 0xe8, 0xe6, 0x01, 0xd5,
+	0xcd, 0x84, 0xc0, 0x5e, 0xe8, 0x06, 0x68, 0xb5, 0x4e, 0x8d, 0x59, 0xcf, 0xd6, 0xdf, 0xe4, 0x08,
+	0x3a, 0xca, 0x57, 0xb7, 0x68, 0xb5, 0xb5, 0x31, 0x5d, 0x10, 0x0b, 0x0e, 0x04, 0xc6, 0x91, 0x50,
+	0xd2, 0xda, 0xd3, 0xb9, 0xf3, 0x25, 0xf9, 0x08, 0x20, 0x70, 0x43, 0xd7, 0x43, 0xe1, 0xf8, 0xdc,
+	0xea, 0xe8, 0xdc, 0xbd, 0xcc, 0xb2, 0xe0, 0xf4, 0x15, 0x3c, 0xc8, 0xcb, 0x4b, 0x1b, 0x65, 0x1c,
+	0x85, 0x12, 0xc9, 0xa7, 0xd0, 0xc3, 0xdc, 0xa8, 0xb1, 0x9a, 0xe7, 0x3d, 0x96, 0x87, 0xd9, 0x5b,
+	0x1f, 0x39, 0x01, 0x33, 0xf0, 0xa5, 0xf4, 0x43, 0xcf, 0x49, 0x68, 0xb5, 0x74, 0x69, 0xc8, 0x4c,
+	0x0b, 0x2e, 0xe9, 0x2b, 0x38, 0x9e, 0x0b, 0x74, 0x15, 0x6e, 0x76, 0xe3, 0x2f, 0x2b, 0x94, 0x8a,
+	0x3c, 0x86, 0x6e, 0x9e, 0x46, 0x13, 0x2e, 0x55, 0xd8, 0xb8, 0x2a, 0xe8, 0x5b, 0x55, 0xf4, 0x2b,
+	0xf8, 0xe0, 0x45, 0xf4, 0xa6, 0x96, 0xbc, 0xaa, 0xe3, 0x27, 0x30, 0x0c, 0xf1, 0xad, 0x53, 0xcb,
+	0xd4, 0x0f, 0xf1, 0xed, 0x8b, 0x3c, 0x19, 0x99, 0xc1, 0x61, 0x10, 0xbd, 0x41, 0x27, 0x53, 0xce,
+	0x51, 0x51, 0xa4, 0x45, 0xee, 0xda, 0xc3, 0xc4, 0x6e, 0xa7, 0xe6, 0xcb, 0x28, 0xa2, 0x3f, 0xc1,
+	0x78, 0x11, 0x24, 0xab, 0x82, 0x74, 0x69, 0xe5, 0x7b, 0x2b, 0x37, 0x81, 0x03, 0x2e, 0xee, 0x1c,
+	0xb1, 0x0a, 0x35, 0x96, 0xae, 0xbd, 0xcf, 0xc5, 0x9d, 0xbd, 0x0a, 0xe9, 0x25, 0x0c, 0xf3, 0xf8,
+	0xf9, 0xd2, 0x0d, 0x3d, 0x24, 0x8f, 0x60, 0xff, 0x0a, 0x6f, 0x22, 0xd1, 0x20, 0x54, 0xe6, 0x20,
+	0x27, 0xd0, 0x71, 0x6f, 0x14, 0x0a, 0xab, 0x55, 0x8d, 0x48, 0xed, 0xf4, 0x77, 0x03, 0x26, 0x35,
+	0xc8, 0xd9, 0x69, 0x7f, 0x0c, 0x07, 0xd7, 0xfa, 0x8c, 0x78, 0x1d, 0x71, 0xee, 0x21, 0x9f, 0xc1,
+	0xc1, 0x2a, 0xe6, 0x3a, 0xa8, 0xa5, 0x83, 0x46, 0xac, 0x0c, 0xd3, 0xce, 0xfd, 0x45, 0x6a, 0xed,
+	0x12, 0xb5, 0x39, 0x1c, 0xa5, 0x22, 0xfa, 0xa1, 0x77, 0x29, 0x76, 0x1f, 0xd7, 0x43, 0xe8, 0x05,
+	0xee, 0xda, 0xe1, 0x18, 0xab, 0xa5, 0x66, 0xd4, 0xb1, 0xbb, 0x81, 0xbb, 0x7e, 0x96, 0xac, 0xa9,
+	0x84, 0x41, 0x29, 0xc9, 0xff, 0x68, 0x9a, 0x59, 0xb9, 0x69, 0xcc, 0xf3, 0x21, 0x2b, 0xe3, 0xcb,
+	0xdd, 0xd4, 0x03, 0xf3, 0x62, 0x75, 0xa5, 0x04, 0xe2, 0xf7, 0x11, 0xc7, 0xfb, 0x5e, 0xde, 0x87,
+	0xd0, 0x8b, 0x5d, 0x81, 0xa1, 0xda, 0xde, 0xb8, 0x6e, 0x6a, 0x58, 0xf0, 0x04, 0x52, 0x4a, 0xb0,
+	0xad, 0x09, 0xa6, 0x0b, 0xfa, 0x23, 0x1c, 0x3e, 0x5f, 0x27, 0x35, 0x7f, 0x10, 0xde, 0x7f, 0x91,
+	0x87, 0x8c, 0x61, 0xff, 0x26, 0x12, 0x81, 0xab, 0x32, 0xaa, 0xd9, 0x8a, 0x3e, 0x02, 0x33, 0x4d,
+	0x3c, 0x5f, 0xae, 0xc2, 0xd7, 0x89, 0x48, 0xdc, 0x55, 0xae, 0xce, 0xda, 0xb7, 0xf5, 0x37, 0xfd,
+	0x12, 0xfa, 0x59, 0x33, 0xcc, 0x97, 0xae, 0x1f, 0x26, 0x2c, 0xb3, 0x8e, 0x69, 0xb8, 0xca, 0x1b,
+	0x17, 0xfd, 0xc3, 0x80, 0xa3, 0xef, 0x7c, 0x59, 0xef, 0x05, 0x4d, 0xdf, 0x43, 0x47, 0xfa, 0xef,
+	0x52, 0x99, 0x3a, 0x09, 0x7d, 0x0f, 0x2f, 0xfc, 0x77, 0xba, 0xb1, 0xb5, 0x53, 0x45, 0xaf, 0x31,
+	0xcc, 0xce, 0x4a, 0x87, 0x5f, 0x26, 0x86, 0x1d, 0x07, 0x76, 0x02, 0x66, 0x72, 0x9c, 0x4e, 0x2c,
+	0xf0, 0xc6, 0x5f, 0x5b, 0x7b, 0xda, 0x07, 0x89, 0xe9, 0xa5, 0xb6, 0xd0, 0x25, 0x1c, 0x57, 0xa0,
+	0xfc, 0xdb, 0x89, 0xf6, 0x04, 0x46, 0x21, 0xae, 0x95, 0x53, 0x03, 0x37, 0x48, 0xcc, 0x2f, 0x73,
+	0x80, 0xf4, 0x37, 0x18, 0x5f, 0xa0, 0x2b, 0xae, 0x97, 0x35, 0xda, 0xf9, 0xfd, 0x33, 0x9a, 0xee,
+	0x5f, 0xab, 0x48, 0xa7, 0x24, 0x50, 0xfb, 0x6f, 0x05, 0xda, 0xab, 0x08, 0x44, 0x7f, 0x86, 0x49,
+	0xad, 0xfe, 0x7b, 0xe2, 0x7a, 0xfe, 0x67, 0x07, 0x3a, 0xfa, 0xc5, 0x23, 0x8f, 0xc1, 0xf4, 0x70,
+	0x23, 0x2f, 0x31, 0xd9, 0xf6, 0x6d, 0x9b, 0x6e, 0x6b, 0x90, 0x33, 0xe8, 0x17, 0xc2, 0x24, 0xe9,
+	0x17, 0xe2, 0xe4, 0x94, 0xb0, 0x3a, 0xe6, 0xa7, 0x30, 0xbc, 0x2e, 0xbd, 0x13, 0x64, 0xcc, 0x1a,
+	0x1f, 0x8e, 0x62, 0x99, 0x27, 0x30, 0x4c, 0x67, 0xce, 0xc6, 0xb2, 0x75, 0x16, 0xe3, 0x66, 0x30,
+	0xe4, 0x78, 0x8b, 0x0a, 0xff, 0x11, 0xf8, 0x17, 0xd0, 0x0f, 0x0a, 0xef, 0x09, 0x39, 0x62, 0x0d,
+	0xcf, 0x4b, 0x71, 0xc3, 0xb7, 0x30, 0xf2, 0xcb, 0x63, 0x95, 0x4c, 0x58, 0xf3, 0xdb, 0x30, 0xb5,
+	0xd8, 0x8e, 0x09, 0x3c, 0x33, 0xc8, 0xd7, 0x70, 0xe8, 0xa1, 0x2a, 0x0f, 0xb6, 0x63, 0xd6, 0x34,
+	0x2d, 0xa7, 0x95, 0x21, 0x45, 0xbe, 0x82, 0x81, 0x54, 0x02, 0xdd, 0x20, 0x9b, 0x50, 0xbb, 0xf6,
+	0xf5, 0x59, 0x61, 0x84, 0x9d, 0x19, 0xe4, 0x73, 0x18, 0x79, 0xa8, 0x4a, 0x1d, 0x5f, 0x92, 0x65,
+	0xc0, 0x4a, 0x3e, 0x06, 0x3d, 0xcc, 0x27, 0x13, 0x79, 0xc0, 0xaa, 0x53, 0x6a, 0xda, 0x67, 0x85,
+	0xf9, 0x72, 0x66, 0x90, 0x6f, 0x60, 0x70, 0x5b, 0x6c, 0x45, 0x72, 0xcc, 0x9a, 0xa6, 0xc4, 0x74,
+	0xcc, 0x9a, 0x3b, 0xf6, 0x19, 0x8c, 0x64, 0xf9, 0x82, 0x93, 0x09, 0x6b, 0x6e, 0xb9, 0xa9, 0xc5,
+	0x76, 0xf4, 0xc2, 0xd5, 0xbe, 0xfe, 0x47, 0x7b, 0xfa, 0xd7, 0x00, 0x3f, 0x9c, 0x32, 0xf8, 0xb2,
+	0x09, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// HrappClient is the client API for Hrapp service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HrappClient interface {
+	GetEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error)
+	GetEmployees(ctx context.Context, in *EmployeeIds, opts ...grpc.CallOption) (*EmployeesResponse, error)
+	CreateEmployee(ctx context.Context, in *CreateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error)
+	UpdateEmployee(ctx context.Context, in *Employee, opts ...grpc.CallOption) (*Employee, error)
+	DeleteEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error)
+	MoveEmployee(ctx context.Context, in *MoveEmployeeRequest, opts ...grpc.CallOption) (*Employee, error)
+	ImportEmployees(ctx context.Context, opts ...grpc.CallOption) (Hrapp_ImportEmployeesClient, error)
+	GetReportingTree(ctx context.Context, in *ReportingTreeRequest, opts ...grpc.CallOption) (*ReportingTree, error)
+	StreamSubtree(ctx context.Context, in *ReportingTreeRequest, opts ...grpc.CallOption) (Hrapp_StreamSubtreeClient, error)
+	GetManagerChain(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*ManagerChain, error)
+	ExportOrg(ctx context.Context, in *ExportOrgRequest, opts ...grpc.CallOption) (Hrapp_ExportOrgClient, error)
+	ListEmployees(ctx context.Context, in *ListEmployeesRequest, opts ...grpc.CallOption) (*ListEmployeesResponse, error)
+	SearchEmployees(ctx context.Context, in *SearchEmployeesRequest, opts ...grpc.CallOption) (*SearchEmployeesResponse, error)
+}
+
+type hrappClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHrappClient(cc *grpc.ClientConn) HrappClient {
+	return &hrappClient{cc}
+}
+
+func (c *hrappClient) GetEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error) {
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, "/hrapp/getEmployee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) GetEmployees(ctx context.Context, in *EmployeeIds, opts ...grpc.CallOption) (*EmployeesResponse, error) {
+	out := new(EmployeesResponse)
+	err := c.cc.Invoke(ctx, "/hrapp/getEmployees", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) CreateEmployee(ctx context.Context, in *CreateEmployeeRequest, opts ...grpc.CallOption) (*Employee, error) {
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, "/hrapp/createEmployee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) UpdateEmployee(ctx context.Context, in *Employee, opts ...grpc.CallOption) (*Employee, error) {
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, "/hrapp/updateEmployee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) DeleteEmployee(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*Employee, error) {
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, "/hrapp/deleteEmployee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) MoveEmployee(ctx context.Context, in *MoveEmployeeRequest, opts ...grpc.CallOption) (*Employee, error) {
+	out := new(Employee)
+	err := c.cc.Invoke(ctx, "/hrapp/moveEmployee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) ImportEmployees(ctx context.Context, opts ...grpc.CallOption) (Hrapp_ImportEmployeesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Hrapp_serviceDesc.Streams[0], "/hrapp/importEmployees", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hrappImportEmployeesClient{stream}
+	return x, nil
+}
+
+type Hrapp_ImportEmployeesClient interface {
+	Send(*ImportEmployeesRequest) error
+	CloseAndRecv() (*ImportEmployeesResponse, error)
+	grpc.ClientStream
+}
+
+type hrappImportEmployeesClient struct {
+	grpc.ClientStream
+}
+
+func (x *hrappImportEmployeesClient) Send(m *ImportEmployeesRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *hrappImportEmployeesClient) CloseAndRecv() (*ImportEmployeesResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportEmployeesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *hrappClient) GetReportingTree(ctx context.Context, in *ReportingTreeRequest, opts ...grpc.CallOption) (*ReportingTree, error) {
+	out := new(ReportingTree)
+	err := c.cc.Invoke(ctx, "/hrapp/getReportingTree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) StreamSubtree(ctx context.Context, in *ReportingTreeRequest, opts ...grpc.CallOption) (Hrapp_StreamSubtreeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Hrapp_serviceDesc.Streams[1], "/hrapp/streamSubtree", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hrappStreamSubtreeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Hrapp_StreamSubtreeClient interface {
+	Recv() (*SubtreeNode, error)
+	grpc.ClientStream
+}
+
+type hrappStreamSubtreeClient struct {
+	grpc.ClientStream
+}
+
+func (x *hrappStreamSubtreeClient) Recv() (*SubtreeNode, error) {
+	m := new(SubtreeNode)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *hrappClient) GetManagerChain(ctx context.Context, in *EmployeeId, opts ...grpc.CallOption) (*ManagerChain, error) {
+	out := new(ManagerChain)
+	err := c.cc.Invoke(ctx, "/hrapp/getManagerChain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) ExportOrg(ctx context.Context, in *ExportOrgRequest, opts ...grpc.CallOption) (Hrapp_ExportOrgClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Hrapp_serviceDesc.Streams[2], "/hrapp/exportOrg", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hrappExportOrgClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Hrapp_ExportOrgClient interface {
+	Recv() (*ExportChunk, error)
+	grpc.ClientStream
+}
+
+type hrappExportOrgClient struct {
+	grpc.ClientStream
+}
+
+func (x *hrappExportOrgClient) Recv() (*ExportChunk, error) {
+	m := new(ExportChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *hrappClient) ListEmployees(ctx context.Context, in *ListEmployeesRequest, opts ...grpc.CallOption) (*ListEmployeesResponse, error) {
+	out := new(ListEmployeesResponse)
+	err := c.cc.Invoke(ctx, "/hrapp/listEmployees", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hrappClient) SearchEmployees(ctx context.Context, in *SearchEmployeesRequest, opts ...grpc.CallOption) (*SearchEmployeesResponse, error) {
+	out := new(SearchEmployeesResponse)
+	err := c.cc.Invoke(ctx, "/hrapp/searchEmployees", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HrappServer is the server API for Hrapp service.
+type HrappServer interface {
+	GetEmployee(context.Context, *EmployeeId) (*Employee, error)
+	GetEmployees(context.Context, *EmployeeIds) (*EmployeesResponse, error)
+	CreateEmployee(context.Context, *CreateEmployeeRequest) (*Employee, error)
+	UpdateEmployee(context.Context, *Employee) (*Employee, error)
+	DeleteEmployee(context.Context, *EmployeeId) (*Employee, error)
+	MoveEmployee(context.Context, *MoveEmployeeRequest) (*Employee, error)
+	ImportEmployees(Hrapp_ImportEmployeesServer) error
+	GetReportingTree(context.Context, *ReportingTreeRequest) (*ReportingTree, error)
+	StreamSubtree(*ReportingTreeRequest, Hrapp_StreamSubtreeServer) error
+	GetManagerChain(context.Context, *EmployeeId) (*ManagerChain, error)
+	ExportOrg(*ExportOrgRequest, Hrapp_ExportOrgServer) error
+	ListEmployees(context.Context, *ListEmployeesRequest) (*ListEmployeesResponse, error)
+	SearchEmployees(context.Context, *SearchEmployeesRequest) (*SearchEmployeesResponse, error)
+}
+
+func RegisterHrappServer(s *grpc.Server, srv HrappServer) {
+	s.RegisterService(&_Hrapp_serviceDesc, srv)
+}
+
+func _Hrapp_GetEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmployeeId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).GetEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/GetEmployee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).GetEmployee(ctx, req.(*EmployeeId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_GetEmployees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmployeeIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).GetEmployees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/GetEmployees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).GetEmployees(ctx, req.(*EmployeeIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_CreateEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).CreateEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/CreateEmployee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).CreateEmployee(ctx, req.(*CreateEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_UpdateEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Employee)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).UpdateEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/UpdateEmployee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).UpdateEmployee(ctx, req.(*Employee))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_DeleteEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmployeeId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).DeleteEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/DeleteEmployee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).DeleteEmployee(ctx, req.(*EmployeeId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_MoveEmployee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveEmployeeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).MoveEmployee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/MoveEmployee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).MoveEmployee(ctx, req.(*MoveEmployeeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_ImportEmployees_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(HrappServer).ImportEmployees(&hrappImportEmployeesServer{stream})
+}
+
+type Hrapp_ImportEmployeesServer interface {
+	SendAndClose(*ImportEmployeesResponse) error
+	Recv() (*ImportEmployeesRequest, error)
+	grpc.ServerStream
+}
+
+type hrappImportEmployeesServer struct {
+	grpc.ServerStream
+}
+
+func (x *hrappImportEmployeesServer) SendAndClose(m *ImportEmployeesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *hrappImportEmployeesServer) Recv() (*ImportEmployeesRequest, error) {
+	m := new(ImportEmployeesRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Hrapp_GetReportingTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportingTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).GetReportingTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/GetReportingTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).GetReportingTree(ctx, req.(*ReportingTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_StreamSubtree_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReportingTreeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HrappServer).StreamSubtree(m, &hrappStreamSubtreeServer{stream})
+}
+
+type Hrapp_StreamSubtreeServer interface {
+	Send(*SubtreeNode) error
+	grpc.ServerStream
+}
+
+type hrappStreamSubtreeServer struct {
+	grpc.ServerStream
+}
+
+func (x *hrappStreamSubtreeServer) Send(m *SubtreeNode) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Hrapp_GetManagerChain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmployeeId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).GetManagerChain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/GetManagerChain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).GetManagerChain(ctx, req.(*EmployeeId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_ExportOrg_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportOrgRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HrappServer).ExportOrg(m, &hrappExportOrgServer{stream})
+}
+
+type Hrapp_ExportOrgServer interface {
+	Send(*ExportChunk) error
+	grpc.ServerStream
+}
+
+type hrappExportOrgServer struct {
+	grpc.ServerStream
+}
+
+func (x *hrappExportOrgServer) Send(m *ExportChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Hrapp_ListEmployees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEmployeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).ListEmployees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/ListEmployees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).ListEmployees(ctx, req.(*ListEmployeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Hrapp_SearchEmployees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchEmployeesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HrappServer).SearchEmployees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/hrapp/SearchEmployees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HrappServer).SearchEmployees(ctx, req.(*SearchEmployeesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Hrapp_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "hrapp",
+	HandlerType: (*HrappServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "getEmployee",
+			Handler:    _Hrapp_GetEmployee_Handler,
+		},
+		{
+			MethodName: "getEmployees",
+			Handler:    _Hrapp_GetEmployees_Handler,
+		},
+		{
+			MethodName: "createEmployee",
+			Handler:    _Hrapp_CreateEmployee_Handler,
+		},
+		{
+			MethodName: "updateEmployee",
+			Handler:    _Hrapp_UpdateEmployee_Handler,
+		},
+		{
+			MethodName: "deleteEmployee",
+			Handler:    _Hrapp_DeleteEmployee_Handler,
+		},
+		{
+			MethodName: "moveEmployee",
+			Handler:    _Hrapp_MoveEmployee_Handler,
+		},
+		{
+			MethodName: "getReportingTree",
+			Handler:    _Hrapp_GetReportingTree_Handler,
+		},
+		{
+			MethodName: "getManagerChain",
+			Handler:    _Hrapp_GetManagerChain_Handler,
+		},
+		{
+			MethodName: "listEmployees",
+			Handler:    _Hrapp_ListEmployees_Handler,
+		},
+		{
+			MethodName: "searchEmployees",
+			Handler:    _Hrapp_SearchEmployees_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "importEmployees",
+			Handler:       _Hrapp_ImportEmployees_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "streamSubtree",
+			Handler:       _Hrapp_StreamSubtree_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "exportOrg",
+			Handler:       _Hrapp_ExportOrg_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "hrapp.proto",
 }
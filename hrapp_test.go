@@ -1,47 +1,36 @@
 package hrapp
 
 import (
+	"bytes"
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/bmizerany/assert"
 	"github.com/bouk/monkey"
+	"github.com/gocql/gocql"
 	"github.com/golang/mock/gomock"
 	"github.com/nilangshah/hrapp/cassandra"
 	"github.com/nilangshah/hrapp/mock"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/balancer/roundrobin"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	yaml "gopkg.in/yaml.v2"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
-var svcAddr = flag.String("connect-addr", "mydomain.com:8086", "The address to listen on for gRPC requests.")
 var empId = flag.Int64("empid", 1, "The address to listen on for gRPC requests.")
-var pretty = flag.Bool("pretty", false, "Print output in pretty JSON")
-var tlsEnabled = flag.Bool("tls-enabled", true, "Connect hrapp service over tls")
-var certPath = flag.String("certpath", "client/certs/127.0.0.1.crt", "Run gRPC service over tls")
-var keyPath = flag.String("keypath", "client/certs/127.0.0.1.key", "Run gRPC service over tls")
-var caPath = flag.String("capath", "client/certs/root-ca.crt", "Run gRPC service over tls")
-
-var wait sync.WaitGroup
-
-var reporting *EmpHierarchy
-
-type EmpHierarchy struct {
-	Id      int64           `json:"id"`
-	Name    string          `json:"name"`
-	Title   string          `json:"title"`
-	Reports []*EmpHierarchy `json:"reports"`
-}
-
-var result sync.Map
 
 var logger *zap.Logger
 var err error
@@ -59,22 +48,22 @@ func testEnd() {
 func testSetup() {
 	flag.Parse()
 	logger, err = zap.NewProduction()
-	logger.Info("Flags", zap.String("connect-addr", *svcAddr), zap.Int64("empid", *empId), zap.Bool("pretty", *pretty), zap.Bool("tls-enabled", *tlsEnabled), zap.Strings("cert,key,ca", []string{*certPath, *keyPath, *caPath}))
+	logger.Info("Flags", zap.Int64("empid", *empId))
 	if err != nil {
 		fmt.Printf("Error occured while creating logger")
 		os.Exit(1)
 	}
 
-	serviceImplConfig := &ServiceImplConfig{DBConfig: &cassandra.CassandraConfig{"127.0.0.1:9042", "hrapp", "ONE"}}
+	serviceImplConfig := &ServiceImplConfig{DBConfig: &cassandra.CassandraConfig{ClusterHosts: "127.0.0.1:9042", Keyspace: "hrapp", Consistency: "ONE"}}
 
 	serviceImpl = NewServiceImpl(serviceImplConfig)
 
 }
 
 func TestServiceImpl(t *testing.T) {
-	empId1 := &EmployeeId{1}
-	employee1 := &Employee{4, "Nilang", "CEO", []int64{2, 3, 7}}
-	empId2 := &EmployeeId{1000}
+	empId1 := &EmployeeId{Id: 1}
+	employee1 := &Employee{Id: 4, Name: "Nilang", Title: "CEO", Reports: []int64{2, 3, 7}}
+	empId2 := &EmployeeId{Id: 1000}
 	employee2 := &Employee{}
 
 	ctrl := gomock.NewController(t)
@@ -88,28 +77,39 @@ func TestServiceImpl(t *testing.T) {
 
 	mockQuery := mock.NewMockQueryInterface(ctrl)
 	mockIter := mock.NewMockIterInterface(ctrl)
+	//the search index is built from an empty table when the store starts
+	mockSession.EXPECT().Query(LISTEMPLOYEES).Return(mockQuery)
+	mockQuery.EXPECT().PageSize(MAXPAGESIZE).Return(mockQuery)
+	mockQuery.EXPECT().PageState(nil).Return(mockQuery)
+	mockQuery.EXPECT().Iter().Return(mockIter)
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false)
+	mockIter.EXPECT().PageState().Return(nil)
+
 	mockSession.EXPECT().Query(GETEMPLOYEE).Return(mockQuery)
 	mockQuery.EXPECT().Bind(empId1.Id).Return(mockQuery)
 	mockQuery.EXPECT().Iter().Return(mockIter)
 
-	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(empId *int64, name *string, title *string, reports *[]int64) {
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) {
 		*empId = employee1.Id
 		*name = employee1.Name
 		*title = employee1.Title
 		*reports = employee1.Reports
+		*managerId = employee1.ManagerId
 	}).Return(true)
 
 	mockSession.EXPECT().Query(GETEMPLOYEE).Return(mockQuery)
 	mockQuery.EXPECT().Bind(empId2.Id).Return(mockQuery)
 	mockQuery.EXPECT().Iter().Return(mockIter)
 
-	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(empId *int64, name *string, title *string, reports *[]int64) {
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) {
 		*empId = employee2.Id
 		*name = employee2.Name
 		*title = employee2.Title
 		*reports = employee2.Reports
+		*managerId = employee2.ManagerId
 	}).Return(false)
 
+	mockIter.EXPECT().Close().Return(nil).Times(3)
 	mockSession.EXPECT().Close()
 
 	serviceImpl.Init(logger)
@@ -122,105 +122,1038 @@ func TestServiceImpl(t *testing.T) {
 	assert.Equal(t, employee1, emp)
 	t.Log("Employee: ", emp)
 	emp, err = serviceImpl.GetEmployee(context.Background(), empId2)
-	if err != nil {
-		t.Error("Error occurred while getemployee")
-	}
-	assert.Equal(t, employee2, emp)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, (*Employee)(nil), emp)
 	assert.Equal(t, serviceImpl.ServiceDesc(), &_Hrapp_serviceDesc)
 	t.Log("Employee: ", emp)
 	serviceImpl.ShutDown()
 }
 
-func BenchmarkFetch(bb *testing.B) {
-	var ans *EmpHierarchy
-	for n := 0; n < bb.N; n++ {
-		client := creategRPCClient(svcAddr)
-		defer client.Close()
-		wait.Add(1)
-		getEmployee(1, NewHrappClient(client))
-		wait.Wait()
-		ans = buildReporting(1)
+func TestEmployeeWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	mockBatch := mock.NewMockBatchInterface(ctrl)
+	impl := &ServiceImpl{logger: logger, empStore: &employeestore{dbSession: mockSession, logger: logger, search: newSearchIndex()}}
+
+	scanEmployee := func(emp *Employee, found bool) *gomock.Call {
+		return mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Do(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) {
+			*empId = emp.Id
+			*name = emp.Name
+			*title = emp.Title
+			*reports = emp.Reports
+			*managerId = emp.ManagerId
+		}).Return(found)
 	}
-	reporting = ans
-	_, err := json.Marshal(reporting)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	//fmt.Println(string(b))
-}
-
-//build reporting hierarchy
-func buildReporting(i int64) *EmpHierarchy {
-	ans := &EmpHierarchy{}
-	res, found := result.Load(i)
-	if found {
-		ans.Id = i
-		ans.Name = res.(*Employee).Name
-		ans.Title = res.(*Employee).Title
-		ans.Reports = make([]*EmpHierarchy,len(res.(*Employee).Reports))
-		for i, report := range res.(*Employee).Reports {
-			ans.Reports[i] = buildReporting(report)
+	mockSession.EXPECT().Query(gomock.Any()).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Iter().Return(mockIter).AnyTimes()
+	mockIter.EXPECT().Close().Return(nil).AnyTimes()
+
+	//create under an existing manager, the row and the manager's reports are written together
+	manager := &Employee{Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2}}
+	mockQuery.EXPECT().Bind(int64(1)).Return(mockQuery)
+	mockQuery.EXPECT().Bind(int64(8)).Return(mockQuery)
+	gomock.InOrder(scanEmployee(manager, true), scanEmployee(&Employee{}, false))
+	mockSession.EXPECT().Batch(gocql.LoggedBatch).Return(mockBatch)
+	gomock.InOrder(
+		mockBatch.EXPECT().Query(INSERTEMPLOYEE, int64(8), "Sam", "VP", []int64{}, int64(1)),
+		mockBatch.EXPECT().Query(ADDREPORT, []int64{8}, int64(1)),
+		mockBatch.EXPECT().ExecuteBatch().Return(nil),
+	)
+
+	emp, err := impl.CreateEmployee(context.Background(), &CreateEmployeeRequest{Employee: &Employee{Id: 8, Name: "Sam", Title: "VP"}, ManagerId: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 8, Name: "Sam", Title: "VP", Reports: []int64{}, ManagerId: 1}, emp)
+
+	//invalid requests never reach the store
+	_, err = impl.CreateEmployee(context.Background(), &CreateEmployeeRequest{Employee: &Employee{Id: 9}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	//employees with reports can't be deleted
+	mockQuery.EXPECT().Bind(int64(1)).Return(mockQuery)
+	scanEmployee(manager, true)
+	_, err = impl.DeleteEmployee(context.Background(), &EmployeeId{Id: 1})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	//the row and the manager's reports are deleted together, a failed batch changes neither
+	mockQuery.EXPECT().Bind(int64(8)).Return(mockQuery)
+	scanEmployee(&Employee{Id: 8, Name: "Sam", Title: "VP", Reports: []int64{}, ManagerId: 1}, true)
+	mockSession.EXPECT().Batch(gocql.LoggedBatch).Return(mockBatch)
+	gomock.InOrder(
+		mockBatch.EXPECT().Query(REMOVEREPORT, []int64{8}, int64(1)),
+		mockBatch.EXPECT().Query(DELETEEMPLOYEE, int64(8)),
+		mockBatch.EXPECT().ExecuteBatch().Return(gocql.ErrNoConnections),
+	)
+	_, err = impl.DeleteEmployee(context.Background(), &EmployeeId{Id: 8})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestGetManagerChain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	impl := &ServiceImpl{logger: logger, empStore: &employeestore{dbSession: mockSession, logger: logger}}
+
+	employees := map[int64]*Employee{
+		1:  {Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2}},
+		2:  {Id: 2, Name: "John", Title: "SVP", Reports: []int64{5}, ManagerId: 1},
+		5:  {Id: 5, Name: "Ashish", Title: "VP", Reports: []int64{15}, ManagerId: 2},
+		15: {Id: 15, Name: "Hiti", Title: "Sr. Director", ManagerId: 5},
+	}
+	var bound int64
+	mockSession.EXPECT().Query(GETEMPLOYEE).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Bind(gomock.Any()).Do(func(id ...interface{}) { bound = id[0].(int64) }).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Iter().Return(mockIter).AnyTimes()
+	mockIter.EXPECT().Close().Return(nil).AnyTimes()
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+		emp, found := employees[bound]
+		if found {
+			*empId, *name, *title, *reports, *managerId = emp.Id, emp.Name, emp.Title, emp.Reports, emp.ManagerId
+		}
+		return found
+	}).AnyTimes()
+
+	chain, err := impl.GetManagerChain(context.Background(), &EmployeeId{Id: 15})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []*Employee{employees[5], employees[2], employees[1]}, chain.Managers)
+
+	chain, err = impl.GetManagerChain(context.Background(), &EmployeeId{Id: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(chain.Managers))
+
+	_, err = impl.GetManagerChain(context.Background(), &EmployeeId{Id: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetEmployees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	impl := &ServiceImpl{logger: logger, empStore: &employeestore{dbSession: mockSession, logger: logger}}
+
+	//every even id exists, the IN query returns rows in no particular order
+	var rows []int64
+	var queries int
+	mockSession.EXPECT().Query(GETEMPLOYEES).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Bind(gomock.Any()).Do(func(ids ...interface{}) {
+		queries++
+		rows = rows[:0]
+		for _, id := range ids[0].([]int64) {
+			if id%2 == 0 {
+				rows = append([]int64{id}, rows...)
+			}
+		}
+	}).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Iter().Return(mockIter).AnyTimes()
+	mockIter.EXPECT().Close().Return(nil).AnyTimes()
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+		if len(rows) == 0 {
+			return false
 		}
+		*empId, *name, *title = rows[0], fmt.Sprint("emp", rows[0]), "Developer"
+		rows = rows[1:]
+		return true
+	}).AnyTimes()
+
+	resp, err := impl.GetEmployees(context.Background(), &EmployeeIds{Ids: []int64{4, 3, 2, 4, 7}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, queries)
+	assert.Equal(t, 2, len(resp.Employees))
+	assert.Equal(t, int64(4), resp.Employees[0].Id)
+	assert.Equal(t, int64(2), resp.Employees[1].Id)
+	assert.Equal(t, []int64{3, 7}, resp.MissingIds)
+
+	ids := make([]int64, MAXINQUERY*2+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	queries = 0
+	resp, err = impl.GetEmployees(context.Background(), &EmployeeIds{Ids: ids})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, queries)
+	assert.Equal(t, MAXINQUERY, len(resp.Employees))
+	assert.Equal(t, MAXINQUERY+1, len(resp.MissingIds))
+
+	_, err = impl.GetEmployees(context.Background(), &EmployeeIds{Ids: make([]int64, MAXBATCHSIZE+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMoveEmployee(t *testing.T) {
+	impl := NewServiceImpl(&ServiceImplConfig{Store: "memory", SeedFile: "resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(logger))
+	defer impl.ShutDown()
+	ctx := context.Background()
+	get := func(id int64) *Employee {
+		emp, err := impl.GetEmployee(ctx, &EmployeeId{Id: id})
+		assert.Equal(t, nil, err)
+		return emp
+	}
+
+	for _, tc := range []struct {
+		req  *MoveEmployeeRequest
+		code codes.Code
+	}{
+		{&MoveEmployeeRequest{Id: 5, NewManagerId: 5, MoveReportsToo: true}, codes.FailedPrecondition},
+		{&MoveEmployeeRequest{Id: 5, NewManagerId: 31, MoveReportsToo: true}, codes.FailedPrecondition},
+		{&MoveEmployeeRequest{Id: 1000, NewManagerId: 3}, codes.NotFound},
+		{&MoveEmployeeRequest{Id: 5, NewManagerId: 1000}, codes.NotFound},
+		//the root can't leave its reports without a manager
+		{&MoveEmployeeRequest{Id: 1, NewManagerId: 3}, codes.InvalidArgument},
+	} {
+		_, err := impl.MoveEmployee(ctx, tc.req)
+		assert.Equal(t, tc.code, status.Code(err))
+	}
+
+	//the whole team moves along
+	emp, err := impl.MoveEmployee(ctx, &MoveEmployeeRequest{Id: 9, NewManagerId: 3, MoveReportsToo: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(3), emp.ManagerId)
+	assert.Equal(t, []int64{19, 25}, emp.Reports)
+	assert.Equal(t, []int64{11, 9}, get(3).Reports)
+	assert.Equal(t, []int64{5}, get(2).Reports)
+
+	//moving under one of its own reports is fine when the reports stay behind
+	emp, err = impl.MoveEmployee(ctx, &MoveEmployeeRequest{Id: 5, NewManagerId: 15})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 5, Name: "Ashish", Title: "VP", Reports: []int64{}, ManagerId: 15}, emp)
+	assert.Equal(t, []int64{15, 16, 17}, get(2).Reports)
+	assert.Equal(t, int64(2), get(16).ManagerId)
+	assert.Equal(t, []int64{31, 32, 5}, get(15).Reports)
+	chain, err := impl.GetManagerChain(ctx, &EmployeeId{Id: 5})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(chain.Managers))
+
+	//cassandra applies all changes in one logged batch
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	mockBatch := mock.NewMockBatchInterface(ctrl)
+	store := &employeestore{dbSession: mockSession, logger: logger}
+	employees := map[int64]*Employee{
+		1: {Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2, 3}},
+		2: {Id: 2, Name: "John", Title: "SVP", Reports: []int64{5}, ManagerId: 1},
+		3: {Id: 3, Name: "Jane", Title: "SVP", ManagerId: 1},
+		5: {Id: 5, Name: "Ashish", Title: "VP", Reports: []int64{15, 16}, ManagerId: 2},
 	}
-	return ans
-}
-
-//Create gRPC client connection to gRPC service
-func creategRPCClient(addr *string) *grpc.ClientConn {
-	//init certs
-	if *tlsEnabled {
-		certificate, err := tls.LoadX509KeyPair(
-			*certPath,
-			*keyPath,
-		)
-		certPool := x509.NewCertPool()
-		bs, err := ioutil.ReadFile(*caPath)
-		if err != nil {
-			logger.Error("failed to read ca cert: %s", zap.Error(err))
+	var bound int64
+	mockSession.EXPECT().Query(GETEMPLOYEE).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Bind(gomock.Any()).Do(func(id ...interface{}) { bound = id[0].(int64) }).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Iter().Return(mockIter).AnyTimes()
+	mockIter.EXPECT().Close().Return(nil).AnyTimes()
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+		emp, found := employees[bound]
+		if found {
+			*empId, *name, *title, *reports, *managerId = emp.Id, emp.Name, emp.Title, emp.Reports, emp.ManagerId
 		}
-		ok := certPool.AppendCertsFromPEM(bs)
-		if !ok {
-			logger.Error("failed to append certs")
+		return found
+	}).AnyTimes()
+
+	_, err = store.MoveEmployee(2, 5, true)
+	assert.Equal(t, ErrHierarchyCycle, errors.Cause(err))
+	_, err = store.MoveEmployee(1, 3, false)
+	assert.Equal(t, ErrInvalidMove, errors.Cause(err))
+
+	mockSession.EXPECT().Batch(gocql.LoggedBatch).Return(mockBatch)
+	gomock.InOrder(
+		mockBatch.EXPECT().Query(SETMANAGER, int64(3), int64(5)),
+		mockBatch.EXPECT().Query(REMOVEREPORT, []int64{5}, int64(2)),
+		mockBatch.EXPECT().Query(ADDREPORT, []int64{5}, int64(3)),
+		mockBatch.EXPECT().Query(REMOVEREPORT, []int64{15, 16}, int64(5)),
+		mockBatch.EXPECT().Query(SETMANAGER, int64(2), int64(15)),
+		mockBatch.EXPECT().Query(SETMANAGER, int64(2), int64(16)),
+		mockBatch.EXPECT().Query(ADDREPORT, []int64{15, 16}, int64(2)),
+		mockBatch.EXPECT().ExecuteBatch().Return(nil),
+	)
+	emp, err = store.MoveEmployee(5, 3, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 5, Name: "Ashish", Title: "VP", Reports: []int64{}, ManagerId: 3}, emp)
+}
+
+func TestStoreErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	impl := &ServiceImpl{logger: logger, empStore: &employeestore{dbSession: mockSession, logger: logger}}
+
+	mockSession.EXPECT().Query(GETEMPLOYEE).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Bind(gomock.Any()).Return(mockQuery).AnyTimes()
+	mockQuery.EXPECT().Iter().Return(mockIter).AnyTimes()
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+
+	for _, tc := range []struct {
+		dbErr error
+		code  codes.Code
+	}{
+		{nil, codes.NotFound},
+		{gocql.ErrTimeoutNoResponse, codes.DeadlineExceeded},
+		{&gocql.RequestErrReadTimeout{}, codes.DeadlineExceeded},
+		{gocql.ErrNoConnections, codes.Unavailable},
+		{&gocql.RequestErrUnavailable{}, codes.Unavailable},
+		{errors.New("syntax error"), codes.Internal},
+	} {
+		mockIter.EXPECT().Close().Return(tc.dbErr)
+		_, err := impl.GetEmployee(context.Background(), &EmployeeId{Id: 1})
+		assert.Equal(t, tc.code, status.Code(err))
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	impl := NewServiceImpl(&ServiceImplConfig{Store: "memory", SeedFile: "resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(logger))
+	defer impl.ShutDown()
+	ctx := context.Background()
+
+	emp, err := impl.GetEmployee(ctx, &EmployeeId{Id: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Nilang", emp.Name)
+
+	_, err = impl.CreateEmployee(ctx, &CreateEmployeeRequest{Employee: &Employee{Id: 200, Name: "Sam", Title: "Developer"}, ManagerId: 53})
+	assert.Equal(t, nil, err)
+	_, err = impl.CreateEmployee(ctx, &CreateEmployeeRequest{Employee: &Employee{Id: 200, Name: "Sam", Title: "Developer"}, ManagerId: 53})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = impl.CreateEmployee(ctx, &CreateEmployeeRequest{Employee: &Employee{Id: 201, Name: "Sam", Title: "Developer"}, ManagerId: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	manager, _ := impl.GetEmployee(ctx, &EmployeeId{Id: 53})
+	assert.Equal(t, []int64{200}, manager.Reports)
+	chain, err := impl.GetManagerChain(ctx, &EmployeeId{Id: 200})
+	assert.Equal(t, nil, err)
+	ids := []int64{}
+	for _, m := range chain.Managers {
+		ids = append(ids, m.Id)
+	}
+	assert.Equal(t, []int64{53, 31, 15, 5, 2, 1}, ids)
+
+	emp, err = impl.UpdateEmployee(ctx, &Employee{Id: 200, Name: "Samuel", Title: "Sr. Developer"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 200, Name: "Samuel", Title: "Sr. Developer", Reports: []int64{}, ManagerId: 53}, emp)
+
+	batch, err := impl.GetEmployees(ctx, &EmployeeIds{Ids: []int64{200, 1000, 53, 200}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(batch.Employees))
+	assert.Equal(t, "Samuel", batch.Employees[0].Name)
+	assert.Equal(t, []int64{1000}, batch.MissingIds)
+
+	_, err = impl.DeleteEmployee(ctx, &EmployeeId{Id: 53})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = impl.DeleteEmployee(ctx, &EmployeeId{Id: 200})
+	assert.Equal(t, nil, err)
+	manager, _ = impl.GetEmployee(ctx, &EmployeeId{Id: 53})
+	assert.Equal(t, []int64{}, manager.Reports)
+	_, err = impl.GetEmployee(ctx, &EmployeeId{Id: 200})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListEmployees(t *testing.T) {
+	impl := NewServiceImpl(&ServiceImplConfig{Store: "memory", SeedFile: "resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(logger))
+	defer impl.ShutDown()
+	ctx := context.Background()
+
+	//walk the whole directory in pages of 40
+	seen := map[int64]bool{}
+	pages := 0
+	req := &ListEmployeesRequest{PageSize: 40}
+	for {
+		resp, err := impl.ListEmployees(ctx, req)
+		assert.Equal(t, nil, err)
+		pages++
+		for _, emp := range resp.Employees {
+			assert.Equal(t, false, seen[emp.Id])
+			seen[emp.Id] = true
 		}
-		transportCreds := credentials.NewTLS(&tls.Config{
-			Certificates: []tls.Certificate{certificate},
-			RootCAs:      certPool,
-		})
-
-		clientConnection, err := grpc.Dial(*addr, grpc.WithTransportCredentials(transportCreds), grpc.WithBalancerName(roundrobin.Name))
-		if err != nil {
-			logger.Error("gRPCClient: error occured whilecreating hrApp client", zap.Error(err))
+		if resp.NextPageToken == "" {
+			break
 		}
-		return clientConnection
-	} else {
-		clientConnection, err := grpc.Dial(*addr, grpc.WithInsecure(), grpc.WithBalancerName(roundrobin.Name))
-		if err != nil {
-			logger.Error("gRPCClient: error occured whilecreating hrApp client", zap.Error(err))
+		req.PageToken = resp.NextPageToken
+	}
+	assert.Equal(t, 98, len(seen))
+	assert.Equal(t, 3, pages)
+
+	resp, err := impl.ListEmployees(ctx, &ListEmployeesRequest{Title: "svp"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(resp.Employees))
+	assert.Equal(t, "", resp.NextPageToken)
+	resp, err = impl.ListEmployees(ctx, &ListEmployeesRequest{NamePrefix: "abhi"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(resp.Employees))
+
+	_, err = impl.ListEmployees(ctx, &ListEmployeesRequest{PageToken: "not a token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = impl.ListEmployees(ctx, &ListEmployeesRequest{PageToken: "bm90IGFuIGlk"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	//the stores list a default page for non-positive sizes
+	for _, size := range []int{0, -1} {
+		employees, next, err := impl.empStore.ListEmployees(&EmployeeFilter{}, size, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 98, len(employees))
+		assert.Equal(t, 0, len(next))
+		employees, _, err = impl.empStore.SearchEmployees(&SearchQuery{Title: "svp"}, size, nil)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, len(employees))
+	}
+
+	//cassandra keeps reading pages until the filter fills one
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	store := &employeestore{dbSession: mockSession, logger: logger}
+	mockSession.EXPECT().Query(LISTEMPLOYEES).Return(mockQuery).Times(2)
+	mockQuery.EXPECT().PageSize(2).Return(mockQuery)
+	mockQuery.EXPECT().PageState(nil).Return(mockQuery)
+	mockQuery.EXPECT().PageSize(1).Return(mockQuery)
+	mockQuery.EXPECT().PageState([]byte("p2")).Return(mockQuery)
+	mockQuery.EXPECT().Iter().Return(mockIter).Times(2)
+	titles := []string{"VP", "Developer", "VP"}
+	gomock.InOrder(
+		mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+			*empId, *title = 1, titles[0]
+			return true
+		}),
+		mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+			*empId, *title = 2, titles[1]
+			return true
+		}),
+		mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false),
+		mockIter.EXPECT().PageState().Return([]byte("p2")),
+		mockIter.EXPECT().Close().Return(nil),
+		mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+			*empId, *title = 3, titles[2]
+			return true
+		}),
+		mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false),
+		mockIter.EXPECT().PageState().Return([]byte("p3")),
+		mockIter.EXPECT().Close().Return(nil),
+	)
+	employees, next, err := store.ListEmployees(&EmployeeFilter{Title: "VP"}, 2, nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(employees))
+	assert.Equal(t, int64(3), employees[1].Id)
+	assert.Equal(t, []byte("p3"), next)
+}
+
+func TestSearchEmployees(t *testing.T) {
+	impl := NewServiceImpl(&ServiceImplConfig{Store: "memory", SeedFile: "resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(logger))
+	defer impl.ShutDown()
+	ctx := context.Background()
+	ids := func(employees []*Employee) []int64 {
+		ids := []int64{}
+		for _, emp := range employees {
+			ids = append(ids, emp.Id)
 		}
-		return clientConnection
+		return ids
 	}
 
+	//prefix matches rank above substring matches
+	resp, err := impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "ASH"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{5, 41}, ids(resp.Employees))
+	resp, err = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "ash", Title: "director"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{41}, ids(resp.Employees))
+
+	//exact matches rank above prefix matches, pages follow the ranking
+	_, err = impl.CreateEmployee(ctx, &CreateEmployeeRequest{Employee: &Employee{Id: 200, Name: "Ash", Title: "Developer"}, ManagerId: 53})
+	assert.Equal(t, nil, err)
+	resp, err = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "ash", PageSize: 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{200, 5}, ids(resp.Employees))
+	resp, err = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "ash", PageSize: 2, PageToken: resp.NextPageToken})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{41}, ids(resp.Employees))
+	assert.Equal(t, "", resp.NextPageToken)
+
+	//the index follows updates and deletes
+	_, err = impl.UpdateEmployee(ctx, &Employee{Id: 200, Name: "Zebediah", Title: "Developer"})
+	assert.Equal(t, nil, err)
+	resp, _ = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "ash"})
+	assert.Equal(t, []int64{5, 41}, ids(resp.Employees))
+	resp, _ = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "zeb"})
+	assert.Equal(t, []int64{200}, ids(resp.Employees))
+	impl.DeleteEmployee(ctx, &EmployeeId{Id: 200})
+	resp, _ = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Name: "zeb"})
+	assert.Equal(t, []int64{}, ids(resp.Employees))
+
+	resp, err = impl.SearchEmployees(ctx, &SearchEmployeesRequest{Title: "vp"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{9, 5, 11, 13, 12}, ids(resp.Employees))
+
+	_, err = impl.SearchEmployees(ctx, &SearchEmployeesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
 }
 
-//Fetch employee details for given employeeId by calling gRPC server
-func getEmployee(empId int64, client HrappClient) {
-	defer wait.Done()
-	response, err := client.GetEmployee(context.Background(), &EmployeeId{Id: empId})
-	if err != nil {
-		fmt.Println(err.Error())
-		logger.Error("Error occured while gRPC service call", zap.Error(err))
-		os.Exit(1)
+func TestSearchIndexRebuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	store := &employeestore{dbSession: mockSession, logger: logger, search: newSearchIndex()}
+	store.search.reset([]*Employee{{Id: 5, Name: "Ashish", Title: "VP"}, {Id: 6, Name: "Ashley", Title: "VP"}})
+
+	//the table is read before 7 is created and 6 deleted through this store, the writes survive the rebuild
+	mockSession.EXPECT().Query(LISTEMPLOYEES).Return(mockQuery)
+	mockQuery.EXPECT().PageSize(MAXPAGESIZE).Return(mockQuery)
+	mockQuery.EXPECT().PageState(nil).Return(mockQuery)
+	mockQuery.EXPECT().Iter().Return(mockIter)
+	rows := []*Employee{{Id: 5, Name: "Ashish", Title: "VP"}, {Id: 6, Name: "Ashley", Title: "VP"}}
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+		if len(rows) == 0 {
+			store.search.put(&Employee{Id: 7, Name: "Asha", Title: "Developer"})
+			store.search.delete(6)
+			return false
+		}
+		*empId, *name, *title = rows[0].Id, rows[0].Name, rows[0].Title
+		rows = rows[1:]
+		return true
+	}).Times(3)
+	mockIter.EXPECT().PageState().Return(nil)
+	mockIter.EXPECT().Close().Return(nil)
+	assert.Equal(t, nil, store.rebuildSearchIndex())
+	assert.Equal(t, []int64{7, 5}, store.search.search(&SearchQuery{Name: "ash"}))
+
+	//writes made after the rebuild aren't recorded any more
+	store.search.put(&Employee{Id: 8, Name: "Ashton", Title: "VP"})
+	assert.Equal(t, 0, len(store.search.changes))
+}
+
+func TestSearchIndexRefresh(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	mockQuery := mock.NewMockQueryInterface(ctrl)
+	mockIter := mock.NewMockIterInterface(ctrl)
+	store := &employeestore{dbSession: mockSession, logger: logger, search: newSearchIndex(), searchRefresh: time.Millisecond}
+
+	//searches don't scan the table, they fail until the index is built
+	_, _, err := store.SearchEmployees(&SearchQuery{Name: "ash"}, 10, nil)
+	assert.Equal(t, ErrStoreUnavailable, errors.Cause(err))
+
+	//the index is rebuilt in the background until the store is closed
+	rebuilt := make(chan struct{}, 1)
+	mockSession.EXPECT().Query(LISTEMPLOYEES).Return(mockQuery).MinTimes(1)
+	mockQuery.EXPECT().PageSize(MAXPAGESIZE).Return(mockQuery).MinTimes(1)
+	mockQuery.EXPECT().PageState(gomock.Any()).Return(mockQuery).MinTimes(1)
+	mockQuery.EXPECT().Iter().Return(mockIter).MinTimes(1)
+	scanned := false
+	mockIter.EXPECT().Scan(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(empId *int64, name *string, title *string, reports *[]int64, managerId *int64) bool {
+		scanned = !scanned
+		*empId, *name, *title = 5, "Ashish", "VP"
+		return scanned
+	}).MinTimes(2)
+	mockIter.EXPECT().PageState().Return(nil).MinTimes(1)
+	mockIter.EXPECT().Close().DoAndReturn(func() error {
+		select {
+		case rebuilt <- struct{}{}:
+		default:
+		}
+		return nil
+	}).MinTimes(1)
+	store.startSearchRefresh()
+	<-rebuilt
+	mockSession.EXPECT().Close()
+	store.Close()
+	_, built := store.search.age()
+	assert.T(t, built)
+	assert.Equal(t, []int64{5}, store.search.search(&SearchQuery{Name: "ash"}))
+}
+
+func TestVerifyOrg(t *testing.T) {
+	store, err := MemoryStoreInit(logger, "resource/hrapp.json")
+	assert.Equal(t, nil, err)
+	report, err := VerifyOrg(store, nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, report.Healthy)
+	assert.Equal(t, int64(1), report.Root)
+	assert.Equal(t, []int64{49, 107}, report.Orphans)
+
+	//the admin endpoint only reports, asking it to repair changes nothing
+	impl := &ServiceImpl{logger: logger, empStore: store}
+	rec := httptest.NewRecorder()
+	impl.ServeVerify(rec, httptest.NewRequest(http.MethodPost, "/verify?repair=true&adopt_orphans_under=3", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	served := &OrgReport{}
+	assert.Equal(t, nil, json.Unmarshal(rec.Body.Bytes(), served))
+	assert.Equal(t, []int64{49, 107}, served.Orphans)
+	assert.Equal(t, 0, len(served.Repaired))
+	emp, _ := store.GetEmployee(&EmployeeId{Id: 49})
+	assert.NotEqual(t, int64(3), emp.ManagerId)
+
+	report, err = VerifyOrg(store, &RepairOptions{AdoptOrphansUnder: 3})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(report.Repaired))
+	report, err = VerifyOrg(store, nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, report.Healthy)
+	emp, _ = store.GetEmployee(&EmployeeId{Id: 49})
+	assert.Equal(t, int64(3), emp.ManagerId)
+
+	//5 and 6 form a loop, 7 is listed by 2 and 3, 4 claims the wrong manager and 2 lists a missing employee
+	employees := []*Employee{
+		{Id: 1, Reports: []int64{2, 3}},
+		{Id: 2, Reports: []int64{4, 7, 99}, ManagerId: 1},
+		{Id: 3, Reports: []int64{7}, ManagerId: 1},
+		{Id: 4, ManagerId: 3},
+		{Id: 5, Reports: []int64{6}, ManagerId: 6},
+		{Id: 6, Reports: []int64{5}, ManagerId: 5},
+		{Id: 7, ManagerId: 3},
+		{Id: 8, ManagerId: 4},
 	}
-	result.Store(empId, response)
-	wait.Add(len(response.Reports))
-	for _, emp := range response.Reports {
-		go getEmployee(emp, client)
+	report = CheckOrg(employees)
+	assert.Equal(t, false, report.Healthy)
+	assert.Equal(t, []int64{1, 8}, report.Roots)
+	assert.Equal(t, [][]int64{{5, 6}}, report.Cycles)
+	assert.Equal(t, []DanglingReport{{ManagerId: 2, ReportId: 99}}, report.DanglingReports)
+	assert.Equal(t, []MultipleParents{{Id: 7, ManagerIds: []int64{2, 3}}}, report.MultipleParents)
+	assert.Equal(t, []ManagerMismatch{{Id: 4, ManagerId: 3, ListedBy: 2}}, report.ManagerMismatches)
+
+	repaired := RepairOrg(employees, report, &RepairOptions{})
+	assert.Equal(t, []*Employee{
+		{Id: 2, Reports: []int64{4}, ManagerId: 1},
+		{Id: 3, Reports: []int64{7}, ManagerId: 1},
+		{Id: 4, Reports: []int64{8}, ManagerId: 2},
+		{Id: 8, Reports: []int64{}, ManagerId: 4},
+	}, repaired)
+}
+
+//importStream feeds requests to ImportEmployees and keeps its response
+type importStream struct {
+	grpc.ServerStream
+	reqs []*ImportEmployeesRequest
+	resp *ImportEmployeesResponse
+}
+
+func (s *importStream) Context() context.Context { return context.Background() }
+func (s *importStream) Recv() (*ImportEmployeesRequest, error) {
+	if len(s.reqs) == 0 {
+		return nil, io.EOF
 	}
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	return req, nil
+}
+func (s *importStream) SendAndClose(resp *ImportEmployeesResponse) error {
+	s.resp = resp
+	return nil
+}
 
-	if err != nil {
-		logger.Error("Error occured while fetching employee data", zap.Error(err))
+func TestImportEmployees(t *testing.T) {
+	impl := NewServiceImpl(&ServiceImplConfig{Store: "memory", SeedFile: "resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(logger))
+	defer impl.ShutDown()
+	ctx := context.Background()
+
+	employees, err := ParseEmployeesCSV(strings.NewReader("id,name,title,manager_id\n201,Ria,Developer,200\n200,Sam,Developer,53\n49,Mukund,Director,3\n"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 201, Name: "Ria", Title: "Developer", ManagerId: 200}, employees[0])
+
+	//dry runs change nothing
+	stream := &importStream{reqs: []*ImportEmployeesRequest{{Employees: employees[:1], DryRun: true}, {Employees: employees[1:]}}}
+	assert.Equal(t, nil, impl.ImportEmployees(stream))
+	assert.Equal(t, true, stream.resp.DryRun)
+	assert.Equal(t, 2, len(stream.resp.Created))
+	assert.Equal(t, 3, len(stream.resp.Updated))
+	_, err = impl.GetEmployee(ctx, &EmployeeId{Id: 200})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	stream = &importStream{reqs: []*ImportEmployeesRequest{{Employees: employees}}}
+	assert.Equal(t, nil, impl.ImportEmployees(stream))
+	emp, err := impl.GetEmployee(ctx, &EmployeeId{Id: 200})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{201}, emp.Reports)
+	emp, _ = impl.GetEmployee(ctx, &EmployeeId{Id: 3})
+	assert.Equal(t, []int64{11, 49}, emp.Reports)
+
+	//the client's JSON nests reports, moving 5 under its own report 15 is a loop and nothing is written
+	employees, err = ParseHierarchyJSON(strings.NewReader(`{"id":15,"name":"Hiti","title":"Sr. Director","reports":[{"id":5,"name":"Ashish","title":"VP","reports":[]},{"id":202,"name":"Jo","title":"Developer","reports":[]}]}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(employees))
+	assert.Equal(t, []int64{KEEPMANAGER, 15, 15}, []int64{employees[0].ManagerId, employees[1].ManagerId, employees[2].ManagerId})
+	stream = &importStream{reqs: []*ImportEmployeesRequest{{Employees: employees}}}
+	assert.Equal(t, codes.InvalidArgument, status.Code(impl.ImportEmployees(stream)))
+	_, err = impl.GetEmployee(ctx, &EmployeeId{Id: 202})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = ParseEmployeesCSV(strings.NewReader("1,Nilang,CEO\n"))
+	assert.Equal(t, ErrInvalidImport, errors.Cause(err))
+	_, err = PlanImport(map[int64]*Employee{}, []*Employee{{Id: 1, Name: "Nilang", ManagerId: 2}})
+	assert.Equal(t, ErrInvalidImport, errors.Cause(err))
+}
+
+func TestImportOrgPartialWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := NewMockEmployeeStore(ctrl)
+	store.EXPECT().ListEmployees(gomock.Any(), gomock.Any(), gomock.Any()).Return([]*Employee{{Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{}}}, nil, nil)
+	incoming := []*Employee{}
+	for id := int64(1001); id <= 1060; id++ {
+		incoming = append(incoming, &Employee{Id: id, Name: fmt.Sprint("developer", id), Title: "Developer", ManagerId: 1})
+	}
+
+	//the first batch is kept when the second fails, the error tells how many rows made it
+	store.EXPECT().PutEmployees(gomock.Any()).Return(nil)
+	store.EXPECT().PutEmployees(gomock.Any()).Return(errors.Wrap(ErrStoreUnavailable, "put employees"))
+	_, err := ImportOrg(store, incoming, false)
+	assert.Equal(t, ErrStoreUnavailable, errors.Cause(err))
+	assert.T(t, strings.Contains(err.Error(), fmt.Sprintf("%d of 61 rows were written", MAXPUTBATCH)))
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hrapp-filestore")
+	assert.Equal(t, nil, err)
+	defer os.RemoveAll(dir)
+
+	store, err := FileStoreInit(logger, dir, "resource/hrapp.json", 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, store.CreateEmployee(&Employee{Id: 200, Name: "Sam", Title: "Developer"}, 53))
+	assert.Equal(t, nil, store.UpdateEmployee(&Employee{Id: 200, Name: "Samuel", Title: "Developer"}))
+	assert.Equal(t, nil, store.CreateEmployee(&Employee{Id: 201, Name: "Ria", Title: "Developer"}, 53))
+	_, err = store.DeleteEmployee(&EmployeeId{Id: 54})
+	assert.Equal(t, nil, err)
+	//failed writes don't reach the log
+	logged, err := ioutil.ReadFile(filepath.Join(dir, LOGFILE))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ErrEmployeeExists, errors.Cause(store.CreateEmployee(&Employee{Id: 201, Name: "Ria"}, 53)))
+	_, err = store.MoveEmployee(201, 201, true)
+	assert.Equal(t, ErrHierarchyCycle, errors.Cause(err))
+	after, err := ioutil.ReadFile(filepath.Join(dir, LOGFILE))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, logged, after)
+
+	//reopen without Close, as after a crash, with a torn entry at the end of the log
+	log, err := os.OpenFile(filepath.Join(dir, LOGFILE), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Equal(t, nil, err)
+	log.WriteString(`{"seq":99,"op":"delete","id"`)
+	log.Close()
+
+	store, err = FileStoreInit(logger, dir, "", 2)
+	assert.Equal(t, nil, err)
+	emp, err := store.GetEmployee(&EmployeeId{Id: 200})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Samuel", emp.Name)
+	emp, err = store.GetEmployee(&EmployeeId{Id: 53})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{200, 201}, emp.Reports)
+	emp, err = store.GetEmployee(&EmployeeId{Id: 31})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{53, 55}, emp.Reports)
+	_, err = store.GetEmployee(&EmployeeId{Id: 54})
+	assert.Equal(t, ErrEmployeeNotFound, errors.Cause(err))
+	store.Close()
+
+	store, err = FileStoreInit(logger, dir, "", 2)
+	assert.Equal(t, nil, err)
+	emp, err = store.GetEmployee(&EmployeeId{Id: 201})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(53), emp.ManagerId)
+
+	//a corrupt entry in the middle of the log is cut off with the entries after it
+	assert.Equal(t, nil, store.UpdateEmployee(&Employee{Id: 201, Name: "Ria", Title: "Lead"}))
+	log, err = os.OpenFile(filepath.Join(dir, LOGFILE), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Equal(t, nil, err)
+	info, err := log.Stat()
+	assert.Equal(t, nil, err)
+	log.WriteString("{\"seq\":\"garbage\"}\n")
+	log.WriteString(`{"seq":1000,"op":"delete","id":201}` + "\n")
+	log.Close()
+
+	store, err = FileStoreInit(logger, dir, "", 2)
+	assert.Equal(t, nil, err)
+	emp, err = store.GetEmployee(&EmployeeId{Id: 201})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Lead", emp.Title)
+	info2, err := os.Stat(filepath.Join(dir, LOGFILE))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, info.Size(), info2.Size())
+	store.Close()
+}
+
+func TestCacheStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewMockEmployeeStore(ctrl)
+	cache := NewCacheStore(logger, backend, 2, time.Minute).(*cachestore)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	nilang := &Employee{Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2}}
+	john := &Employee{Id: 2, Name: "John", Title: "SVP", Reports: []int64{}, ManagerId: 1}
+	jane := &Employee{Id: 3, Name: "Jane", Title: "SVP", Reports: []int64{}, ManagerId: 1}
+
+	//first read misses, second one hits
+	backend.EXPECT().GetEmployee(&EmployeeId{Id: 1}).Return(nilang, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		emp, err := cache.GetEmployee(&EmployeeId{Id: 1})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nilang, emp)
+	}
+
+	//size 2 evicts the least recently used entry
+	backend.EXPECT().GetEmployee(&EmployeeId{Id: 2}).Return(john, nil).Times(1)
+	backend.EXPECT().GetEmployee(&EmployeeId{Id: 3}).Return(jane, nil).Times(1)
+	cache.GetEmployee(&EmployeeId{Id: 2})
+	cache.GetEmployee(&EmployeeId{Id: 1})
+	cache.GetEmployee(&EmployeeId{Id: 3})
+	_, cached := cache.entries[2]
+	assert.Equal(t, false, cached)
+	assert.Equal(t, 2, cache.lru.Len())
+
+	//expired entries are read again
+	now = now.Add(2 * time.Minute)
+	backend.EXPECT().GetEmployee(&EmployeeId{Id: 3}).Return(jane, nil).Times(1)
+	cache.GetEmployee(&EmployeeId{Id: 3})
+
+	//writes invalidate the employee and its manager
+	backend.EXPECT().DeleteEmployee(&EmployeeId{Id: 3}).Return(jane, nil)
+	cache.DeleteEmployee(&EmployeeId{Id: 3})
+	assert.Equal(t, 0, cache.lru.Len())
+
+	//concurrent misses share one backend call
+	release := make(chan struct{})
+	backend.EXPECT().GetEmployee(&EmployeeId{Id: 2}).DoAndReturn(func(id *EmployeeId) (*Employee, error) {
+		<-release
+		return john, nil
+	}).Times(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			emp, err := cache.GetEmployee(&EmployeeId{Id: 2})
+			assert.Equal(t, nil, err)
+			assert.Equal(t, john, emp)
+		}()
 	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	//batches only ask the backend for the ids that aren't cached
+	backend.EXPECT().GetEmployees([]int64{1, 4}).Return([]*Employee{nilang}, []int64{4}, nil).Times(1)
+	employees, missing, err := cache.GetEmployees([]int64{2, 1, 4, 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []*Employee{john, nilang}, employees)
+	assert.Equal(t, []int64{4}, missing)
 }
 
+func TestReload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	cache := NewCacheStore(logger, &employeestore{dbSession: mockSession, logger: logger}, 10, time.Minute).(*cachestore)
+	config := &ServiceImplConfig{Store: "cassandra", DBConfig: &cassandra.CassandraConfig{ClusterHosts: "127.0.0.1:9042", Keyspace: "hrapp", Consistency: "ONE"}, CacheSize: 10, CacheTTL: time.Minute}
+	impl := &ServiceImpl{logger: logger, Config: config, empStore: cache}
+
+	//nothing changes until the checked settings are applied
+	apply, err := impl.PrepareReload(&ServiceImplConfig{Store: "cassandra", DBConfig: &cassandra.CassandraConfig{ClusterHosts: "127.0.0.1:9042", Keyspace: "hrapp", Consistency: "QUORUM"}, CacheSize: 20, CacheTTL: 5 * time.Minute})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, time.Minute, cache.ttl)
+	assert.Equal(t, "ONE", config.DBConfig.Consistency)
+
+	//the cache TTL and the consistency change, the cache size needs a restart
+	mockSession.EXPECT().SetConsistency(gocql.Quorum).Times(1)
+	apply()
+	assert.Equal(t, 5*time.Minute, cache.ttl)
+	assert.Equal(t, 5*time.Minute, config.CacheTTL)
+	assert.Equal(t, "QUORUM", config.DBConfig.Consistency)
+	assert.Equal(t, 10, config.CacheSize)
+
+	//an unknown consistency fails before the cache TTL changes
+	_, err = impl.PrepareReload(&ServiceImplConfig{Store: "cassandra", DBConfig: &cassandra.CassandraConfig{Consistency: "SOME"}, CacheTTL: time.Minute})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 5*time.Minute, cache.ttl)
+	assert.Equal(t, "QUORUM", config.DBConfig.Consistency)
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert.NotEqual(t, nil, (&ServiceImpl{logger: logger}).HealthCheck())
+
+	//the cache passes the check to cassandra
+	mockSession := mock.NewMockSessionInterface(ctrl)
+	impl := &ServiceImpl{logger: logger, empStore: NewCacheStore(logger, &employeestore{dbSession: mockSession, logger: logger}, 10, time.Minute)}
+	mockSession.EXPECT().Health().Return(true)
+	assert.Equal(t, nil, impl.HealthCheck())
+	mockSession.EXPECT().Health().Return(false)
+	assert.NotEqual(t, nil, impl.HealthCheck())
+
+	//stores without a backend are always healthy
+	impl.empStore = NewMockEmployeeStore(ctrl)
+	assert.Equal(t, nil, impl.HealthCheck())
+}
+
+//mockEmployees returns a MockEmployeeStore serving GetEmployee from employees
+func mockEmployees(ctrl *gomock.Controller, employees map[int64]*Employee) *MockEmployeeStore {
+	store := NewMockEmployeeStore(ctrl)
+	store.EXPECT().GetEmployee(gomock.Any()).DoAndReturn(func(id *EmployeeId) (*Employee, error) {
+		if emp, ok := employees[id.Id]; ok {
+			return emp, nil
+		}
+		return nil, ErrEmployeeNotFound
+	}).AnyTimes()
+	return store
+}
+
+func TestGetReportingTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	impl := &ServiceImpl{logger: logger, empStore: mockEmployees(ctrl, map[int64]*Employee{
+		1: {Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2, 3}},
+		2: {Id: 2, Name: "John", Title: "SVP", Reports: []int64{4, 99}},
+		3: {Id: 3, Name: "Jane", Title: "SVP", Reports: []int64{1}},
+		4: {Id: 4, Name: "Ashish", Title: "VP", Reports: []int64{}},
+	})}
+
+	tree, err := impl.GetReportingTree(context.Background(), &ReportingTreeRequest{Id: 1})
+	assert.Equal(t, nil, err)
+	//99 doesn't exist and 1 under 3 is a cycle, both are skipped
+	assert.Equal(t, &ReportingTree{Id: 1, Name: "Nilang", Title: "CEO", Reports: []*ReportingTree{
+		{Id: 2, Name: "John", Title: "SVP", Reports: []*ReportingTree{
+			{Id: 4, Name: "Ashish", Title: "VP", Reports: []*ReportingTree{}},
+		}},
+		{Id: 3, Name: "Jane", Title: "SVP", Reports: []*ReportingTree{}},
+	}}, tree)
+
+	tree, err = impl.GetReportingTree(context.Background(), &ReportingTreeRequest{Id: 2, MaxDepth: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(tree.Reports))
+	assert.Equal(t, 0, len(tree.Reports[0].Reports))
+
+	_, err = impl.GetReportingTree(context.Background(), &ReportingTreeRequest{Id: 1000})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
 
+//subtreeStream collects the messages sent by StreamSubtree
+type subtreeStream struct {
+	grpc.ServerStream
+	nodes []*SubtreeNode
+}
+
+func (s *subtreeStream) Context() context.Context { return context.Background() }
+func (s *subtreeStream) Send(node *SubtreeNode) error {
+	s.nodes = append(s.nodes, node)
+	return nil
+}
+
+func TestStreamSubtree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	employees := map[int64]*Employee{
+		1: {Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2, 3}},
+		2: {Id: 2, Name: "John", Title: "SVP", Reports: []int64{4}},
+		3: {Id: 3, Name: "Jane", Title: "SVP", Reports: []int64{1}},
+		4: {Id: 4, Name: "Ashish", Title: "VP", Reports: []int64{}},
+	}
+	impl := &ServiceImpl{logger: logger, empStore: mockEmployees(ctrl, employees)}
+
+	stream := &subtreeStream{}
+	err := impl.StreamSubtree(&ReportingTreeRequest{Id: 1}, stream)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []*SubtreeNode{
+		{Employee: employees[1]},
+		{Employee: employees[2], ParentId: 1, Depth: 1},
+		{Employee: employees[3], ParentId: 1, Depth: 1},
+		{Employee: employees[4], ParentId: 2, Depth: 2},
+	}, stream.nodes)
+
+	stream = &subtreeStream{}
+	err = impl.StreamSubtree(&ReportingTreeRequest{Id: 1, MaxDepth: 1}, stream)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(stream.nodes))
+}
+
+//exportStream collects the chunks sent by ExportOrg
+type exportStream struct {
+	grpc.ServerStream
+	chunks [][]byte
+}
+
+func (s *exportStream) Context() context.Context { return context.Background() }
+func (s *exportStream) Send(chunk *ExportChunk) error {
+	s.chunks = append(s.chunks, chunk.Data)
+	return nil
+}
+
+func TestExportOrg(t *testing.T) {
+	tree := &ReportingTree{Id: 1, Name: "Nilang", Title: "CEO", Reports: []*ReportingTree{
+		{Id: 2, Name: "John \"JJ\"", Title: "SVP", Reports: []*ReportingTree{
+			{Id: 4, Name: "Ashish", Title: "VP"},
+		}},
+		{Id: 3, Name: "Jane", Title: "SVP"},
+	}}
+	expected := map[string]string{
+		"csv": "id,name,title,manager_id\n1,Nilang,CEO,7\n2,\"John \"\"JJ\"\"\",SVP,1\n4,Ashish,VP,2\n3,Jane,SVP,1\n",
+		"dot": "digraph org {\n    node [shape=box];\n    1 [label=\"Nilang\\nCEO\"];\n    2 [label=\"John \\\"JJ\\\"\\nSVP\"];\n" +
+			"    4 [label=\"Ashish\\nVP\"];\n    3 [label=\"Jane\\nSVP\"];\n    1 -> 2;\n    1 -> 3;\n    2 -> 4;\n}\n",
+		"mermaid": "graph TD\n    e1[\"Nilang<br/>CEO\"]\n    e2[\"John #quot;JJ#quot;<br/>SVP\"]\n    e4[\"Ashish<br/>VP\"]\n    e3[\"Jane<br/>SVP\"]\n" +
+			"    e1 --> e2\n    e1 --> e3\n    e2 --> e4\n",
+		"text": "Nilang, CEO [1]\n├── John \"JJ\", SVP [2]\n│   └── Ashish, VP [4]\n└── Jane, SVP [3]\n",
+	}
+	for format, out := range expected {
+		var b strings.Builder
+		assert.Equal(t, nil, WriteOrg(&b, format, tree, 7))
+		assert.Equal(t, out, b.String(), format)
+	}
+
+	//json and yaml have the shape of the client's Hierarchy
+	for _, format := range []string{"json", "yaml"} {
+		var b strings.Builder
+		assert.Equal(t, nil, WriteOrg(&b, format, tree, 0))
+		node := &hierarchyNode{}
+		if format == "json" {
+			assert.Equal(t, nil, json.Unmarshal([]byte(b.String()), node))
+		} else {
+			assert.Equal(t, nil, yaml.Unmarshal([]byte(b.String()), node))
+		}
+		assert.Equal(t, toHierarchyNode(tree), node, format)
+	}
+
+	//csv exports import back unchanged
+	var b strings.Builder
+	assert.Equal(t, nil, WriteOrg(&b, "csv", tree, 0))
+	employees, err := ParseEmployeesCSV(strings.NewReader(b.String()))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &Employee{Id: 4, Name: "Ashish", Title: "VP", ManagerId: 2}, employees[2])
+
+	assert.NotEqual(t, nil, WriteOrg(&b, "xml", tree, 0))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	impl := &ServiceImpl{logger: logger, empStore: mockEmployees(ctrl, map[int64]*Employee{
+		1: {Id: 1, Name: "Nilang", Title: "CEO", Reports: []int64{2}},
+		2: {Id: 2, Name: strings.Repeat("x", EXPORTCHUNKSIZE), Title: "SVP", Reports: []int64{}, ManagerId: 1},
+	})}
+	stream := &exportStream{}
+	assert.Equal(t, nil, impl.ExportOrg(&ExportOrgRequest{Id: 1, Format: "text"}, stream))
+	assert.Equal(t, 2, len(stream.chunks))
+	assert.Equal(t, EXPORTCHUNKSIZE, len(stream.chunks[0]))
+	assert.Equal(t, "Nilang, CEO [1]\n└── "+strings.Repeat("x", EXPORTCHUNKSIZE)+", SVP [2]\n", string(bytes.Join(stream.chunks, nil)))
+
+	err = impl.ExportOrg(&ExportOrgRequest{Id: 1, Format: "xml"}, &exportStream{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	err = impl.ExportOrg(&ExportOrgRequest{Id: 1000}, &exportStream{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,279 @@
+package hrappclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+	"time"
+
+	h "github.com/nilangshah/hrapp"
+	"github.com/nilangshah/hrapp/grpcserver"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/roundrobin"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	//Default GetEmployees calls in flight when walking the hierarchy
+	DEFAULTCONCURRENCY = 4
+	//Default wait before the first retry, doubled for every following one
+	DEFAULTRETRYBACKOFF = 100 * time.Millisecond
+	//Employees sent per ImportEmployees message by Import
+	IMPORTCHUNKSIZE = 1000
+)
+
+//Config configures a Client
+type Config struct {
+	Address   string
+	TlsConfig *grpcserver.TlsConfig
+	//DialTimeout bounds connecting to the service, 0 returns at once and connects in the background
+	DialTimeout time.Duration
+	//CallTimeout bounds every attempt of a unary RPC, 0 for no limit
+	CallTimeout time.Duration
+	//Retries is how many times a read failing with UNAVAILABLE, or running out of CallTimeout, is tried again.
+	//Writes are never retried
+	Retries int
+	//RetryBackoff is the wait before the first retry, DEFAULTRETRYBACKOFF when 0. It doubles for every following
+	//retry up to MaxRetryBackoff, DEFAULTMAXRETRYBACKOFF when 0, and every wait is cut by up to half at random
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	//HedgeDelay enables hedging of reads, another request is sent every HedgeDelay until one answers or
+	//MaxHedges more were sent, 1 when 0. 0 disables hedging
+	HedgeDelay time.Duration
+	MaxHedges  int
+}
+
+//FetchOptions selects the part of the hierarchy returned by FetchHierarchy
+type FetchOptions struct {
+	//MaxDepth is the number of levels of reports below the root, 0 for all levels
+	MaxDepth int
+	//IncludeManagers nests the root under the chain of its managers, each with the root's branch only
+	IncludeManagers bool
+	//Walk crawls the hierarchy with GetEmployees calls for the reports of every manager instead of a single GetReportingTree call
+	Walk bool
+	//Concurrency is the number of GetEmployees calls in flight with Walk, DEFAULTCONCURRENCY when 0
+	Concurrency int
+	//Timeout bounds the whole fetch, 0 for no limit
+	Timeout time.Duration
+}
+
+//Hierarchy is an employee with its reports, the JSON printed by the client
+type Hierarchy struct {
+	Id      int64        `json:"id"`
+	Name    string       `json:"name"`
+	Title   string       `json:"title"`
+	Reports []*Hierarchy `json:"reports"`
+}
+
+//Client calls the hrapp service
+type Client struct {
+	config  *Config
+	logger  *zap.Logger
+	conn    *grpc.ClientConn
+	hrapp   h.HrappClient
+	retrier *retrier
+}
+
+//New connects to the hrapp service at config.Address
+func New(logger *zap.Logger, config *Config) (*Client, error) {
+	registerMetrics.Do(func() {
+		prometheus.MustRegister(clientRetries, clientHedges, clientHedgeWins)
+	})
+	retrier := newRetrier(logger, config)
+	opts := []grpc.DialOption{
+		grpc.WithBalancerName(roundrobin.Name),
+		grpc.WithUnaryInterceptor(retrier.unaryInterceptor),
+	}
+	if config.TlsConfig != nil && config.TlsConfig.TlsEnabled {
+		creds, err := transportCredentials(config.TlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	ctx := context.Background()
+	if config.DialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.DialTimeout)
+		defer cancel()
+		opts = append(opts, grpc.WithBlock())
+	}
+	conn, err := grpc.DialContext(ctx, config.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %s", config.Address)
+	}
+	return &Client{config: config, logger: logger, conn: conn, hrapp: h.NewHrappClient(conn), retrier: retrier}, nil
+}
+
+//Stats returns how many calls of this client were retried and hedged so far
+func (c *Client) Stats() CallStats {
+	return CallStats{
+		Retries:   atomic.LoadInt64(&c.retrier.stats.Retries),
+		Hedges:    atomic.LoadInt64(&c.retrier.stats.Hedges),
+		HedgeWins: atomic.LoadInt64(&c.retrier.stats.HedgeWins),
+	}
+}
+
+//Close closes the connection to the service
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+//Hrapp returns the generated client, unary calls are timed out, retried and hedged as configured
+func (c *Client) Hrapp() h.HrappClient {
+	return c.hrapp
+}
+
+//GetEmployee fetches one employee
+func (c *Client) GetEmployee(ctx context.Context, id int64) (*h.Employee, error) {
+	return c.hrapp.GetEmployee(ctx, &h.EmployeeId{Id: id})
+}
+
+//GetEmployees fetches up to MAXBATCHSIZE employees and the ids that don't exist
+func (c *Client) GetEmployees(ctx context.Context, ids []int64) (*h.EmployeesResponse, error) {
+	return c.hrapp.GetEmployees(ctx, &h.EmployeeIds{Ids: ids})
+}
+
+//FetchHierarchy returns root and its reports as selected by opts, nil opts fetches everything with GetReportingTree.
+//A walk that fails to fetch some employees returns what it fetched along with a *CrawlError
+func (c *Client) FetchHierarchy(ctx context.Context, root int64, opts *FetchOptions) (*Hierarchy, error) {
+	if opts == nil {
+		opts = &FetchOptions{}
+	}
+	if opts.MaxDepth < 0 || opts.Concurrency < 0 || opts.Timeout < 0 {
+		return nil, errors.Errorf("invalid fetch options, max depth %d, concurrency %d and timeout %s must not be negative", opts.MaxDepth, opts.Concurrency, opts.Timeout)
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	var ans *Hierarchy
+	var err error
+	if opts.Walk {
+		concurrency := opts.Concurrency
+		if concurrency == 0 {
+			concurrency = DEFAULTCONCURRENCY
+		}
+		ans, err = c.crawl(ctx, root, opts.MaxDepth, concurrency)
+	} else {
+		var tree *h.ReportingTree
+		tree, err = c.hrapp.GetReportingTree(ctx, &h.ReportingTreeRequest{Id: root, MaxDepth: int32(opts.MaxDepth)})
+		if err == nil {
+			ans = NewHierarchy(tree)
+		}
+	}
+	if ans == nil {
+		return nil, err
+	}
+	if opts.IncludeManagers {
+		withManagers, managersErr := c.addManagers(ctx, ans)
+		if managersErr != nil {
+			return nil, managersErr
+		}
+		ans = withManagers
+	}
+	return ans, err
+}
+
+//ExportOrg copies the hierarchy below root rendered by the ExportOrg RPC to w as the chunks arrive.
+//The stream is bounded by ctx only, CallTimeout and Retries don't apply
+func (c *Client) ExportOrg(ctx context.Context, root int64, maxDepth int, format string, w io.Writer) error {
+	stream, err := c.hrapp.ExportOrg(ctx, &h.ExportOrgRequest{Id: root, MaxDepth: int32(maxDepth), Format: format})
+	if err != nil {
+		return err
+	}
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
+
+//Import streams employees to the ImportEmployees RPC, IMPORTCHUNKSIZE per message, and returns the changes
+//the service made, or would make with dryRun. The stream is bounded by ctx only, CallTimeout and Retries don't apply
+func (c *Client) Import(ctx context.Context, employees []*h.Employee, dryRun bool) (*h.ImportEmployeesResponse, error) {
+	stream, err := c.hrapp.ImportEmployees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for start := 0; start < len(employees); start += IMPORTCHUNKSIZE {
+		end := start + IMPORTCHUNKSIZE
+		if end > len(employees) {
+			end = len(employees)
+		}
+		if err := stream.Send(&h.ImportEmployeesRequest{Employees: employees[start:end], DryRun: dryRun}); err == io.EOF {
+			//the service ended the stream, its error is returned by CloseAndRecv
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+//NewHierarchy converts a ReportingTree to a Hierarchy
+func NewHierarchy(tree *h.ReportingTree) *Hierarchy {
+	ans := &Hierarchy{Id: tree.Id, Name: tree.Name, Title: tree.Title}
+	ans.Reports = make([]*Hierarchy, len(tree.Reports))
+	for j, report := range tree.Reports {
+		ans.Reports[j] = NewHierarchy(report)
+	}
+	return ans
+}
+
+//ReportingTree converts the hierarchy back to the proto message rendered by WriteOrg
+func (ans *Hierarchy) ReportingTree() *h.ReportingTree {
+	tree := &h.ReportingTree{Id: ans.Id, Name: ans.Name, Title: ans.Title}
+	tree.Reports = make([]*h.ReportingTree, len(ans.Reports))
+	for j, report := range ans.Reports {
+		tree.Reports[j] = report.ReportingTree()
+	}
+	return tree
+}
+
+//addManagers nests ans under the chain of its managers, the top manager is returned
+func (c *Client) addManagers(ctx context.Context, ans *Hierarchy) (*Hierarchy, error) {
+	chain, err := c.hrapp.GetManagerChain(ctx, &h.EmployeeId{Id: ans.Id})
+	if err != nil {
+		return nil, err
+	}
+	for _, manager := range chain.Managers {
+		ans = &Hierarchy{Id: manager.Id, Name: manager.Name, Title: manager.Title, Reports: []*Hierarchy{ans}}
+	}
+	return ans, nil
+}
+
+//transportCredentials loads the client certificate and the CA the server certificate must be signed by
+func transportCredentials(config *grpcserver.TlsConfig) (credentials.TransportCredentials, error) {
+	certificate, err := tls.LoadX509KeyPair(config.CertPath, config.KeyPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load client certificate")
+	}
+	bs, err := ioutil.ReadFile(config.CAPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read ca cert")
+	}
+	certPool := x509.NewCertPool()
+	if !certPool.AppendCertsFromPEM(bs) {
+		return nil, errors.Errorf("no certificates found in %s", config.CAPath)
+	}
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		RootCAs:      certPool,
+	}), nil
+}
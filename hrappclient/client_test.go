@@ -0,0 +1,267 @@
+package hrappclient
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	h "github.com/nilangshah/hrapp"
+	"github.com/nilangshah/hrapp/grpcserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var svcAddr = flag.String("connect-addr", "mydomain.com:8086", "The address of the hrapp service BenchmarkFetch calls")
+var tlsEnabled = flag.Bool("tls-enabled", true, "Connect hrapp service over tls")
+var certPath = flag.String("certpath", "../client/certs/127.0.0.1.crt", "Client certificate path")
+var keyPath = flag.String("keypath", "../client/certs/127.0.0.1.key", "Client key path")
+var caPath = flag.String("capath", "../client/certs/root-ca.crt", "CA certificate path")
+
+//startServer serves the sample data from the memory store on a local port
+func startServer(t *testing.T) (*Client, func()) {
+	impl := h.NewServiceImpl(&h.ServiceImplConfig{Store: "memory", SeedFile: "../resource/hrapp.json"})
+	assert.Equal(t, nil, impl.Init(zap.NewNop()))
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	server := grpc.NewServer()
+	server.RegisterService(impl.ServiceDesc(), impl)
+	go server.Serve(lis)
+
+	client, err := New(zap.NewNop(), &Config{Address: lis.Addr().String(), DialTimeout: 5 * time.Second, CallTimeout: 5 * time.Second})
+	assert.Equal(t, nil, err)
+	return client, func() {
+		client.Close()
+		server.Stop()
+	}
+}
+
+func TestFetchHierarchy(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+	ctx := context.Background()
+
+	tree, err := client.FetchHierarchy(ctx, 1, nil)
+	assert.Equal(t, nil, err)
+	walked, err := client.FetchHierarchy(ctx, 1, &FetchOptions{Walk: true, Concurrency: 3})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, tree, walked)
+
+	for _, walk := range []bool{false, true} {
+		ans, err := client.FetchHierarchy(ctx, 2, &FetchOptions{MaxDepth: 1, Walk: walk})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &Hierarchy{Id: 2, Name: "John", Title: "SVP", Reports: []*Hierarchy{
+			{Id: 5, Name: "Ashish", Title: "VP", Reports: []*Hierarchy{}},
+			{Id: 9, Name: "Andrew", Title: "VP", Reports: []*Hierarchy{}},
+		}}, ans)
+
+		ans, err = client.FetchHierarchy(ctx, 5, &FetchOptions{MaxDepth: 1, IncludeManagers: true, Walk: walk})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), ans.Id)
+		assert.Equal(t, int64(2), ans.Reports[0].Id)
+		assert.Equal(t, 1, len(ans.Reports))
+		assert.Equal(t, int64(5), ans.Reports[0].Reports[0].Id)
+
+		_, err = client.FetchHierarchy(ctx, 1000, &FetchOptions{Walk: walk})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	var b strings.Builder
+	assert.Equal(t, nil, client.ExportOrg(ctx, 2, 1, "text", &b))
+	assert.Equal(t, "John, SVP [2]\n├── Ashish, VP [5]\n└── Andrew, VP [9]\n", b.String())
+}
+
+func TestImport(t *testing.T) {
+	client, stop := startServer(t)
+	defer stop()
+	ctx := context.Background()
+	employees := []*h.Employee{}
+	for id := int64(1001); id <= 1000+IMPORTCHUNKSIZE+10; id++ {
+		employees = append(employees, &h.Employee{Id: id, Name: fmt.Sprint("developer", id), Title: "Developer", ManagerId: 53})
+	}
+
+	resp, err := client.Import(ctx, employees, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, resp.DryRun)
+	assert.Equal(t, len(employees), len(resp.Created))
+	_, err = client.GetEmployee(ctx, 1001)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	resp, err = client.Import(ctx, employees, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, resp.DryRun)
+	assert.Equal(t, 1, len(resp.Updated))
+	emp, err := client.GetEmployee(ctx, 1000+IMPORTCHUNKSIZE+10)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(53), emp.ManagerId)
+
+	_, err = client.Import(ctx, []*h.Employee{{Id: 5, Name: "Ashish", ManagerId: 1000}}, false)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRetries(t *testing.T) {
+	r := newRetrier(zap.NewNop(), &Config{Retries: 2, RetryBackoff: time.Millisecond})
+	retries := testutil.ToFloat64(clientRetries.WithLabelValues("getemployee", "Unavailable"))
+	attempts := 0
+	unavailable := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.Unavailable, "connection refused")
+	}
+	err := r.unaryInterceptor(context.Background(), "/hrapp/getEmployee", &h.EmployeeId{Id: 1}, &h.Employee{}, nil, unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, retries+2, testutil.ToFloat64(clientRetries.WithLabelValues("getemployee", "Unavailable")))
+	assert.Equal(t, CallStats{Retries: 2}, r.stats)
+
+	//writes are not retried
+	attempts = 0
+	err = r.unaryInterceptor(context.Background(), "/hrapp/createEmployee", &h.CreateEmployeeRequest{}, &h.Employee{}, nil, unavailable)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, attempts)
+
+	//errors other than UNAVAILABLE are final
+	attempts = 0
+	err = r.unaryInterceptor(context.Background(), "/hrapp/getEmployee", &h.EmployeeId{Id: 1000}, &h.Employee{}, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempts++
+		return status.Error(codes.NotFound, "employee 1000 not found")
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestHedging(t *testing.T) {
+	r := newRetrier(zap.NewNop(), &Config{HedgeDelay: 10 * time.Millisecond})
+	hedges, wins := testutil.ToFloat64(clientHedges.WithLabelValues("getemployee")), testutil.ToFloat64(clientHedgeWins.WithLabelValues("getemployee"))
+	var mu sync.Mutex
+	attempts := 0
+	cancelled := make(chan bool, 1)
+	//the first request hangs until it is cancelled, the hedged one answers
+	slowFirst := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		mu.Lock()
+		attempts++
+		first := attempts == 1
+		mu.Unlock()
+		if first {
+			<-ctx.Done()
+			cancelled <- true
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		*reply.(*h.Employee) = h.Employee{Id: 1, Name: "Nilang"}
+		return nil
+	}
+	reply := &h.Employee{}
+	err := r.unaryInterceptor(context.Background(), "/hrapp/getEmployee", &h.EmployeeId{Id: 1}, reply, nil, slowFirst)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Nilang", reply.Name)
+	assert.T(t, <-cancelled)
+	assert.Equal(t, hedges+1, testutil.ToFloat64(clientHedges.WithLabelValues("getemployee")))
+	assert.Equal(t, wins+1, testutil.ToFloat64(clientHedgeWins.WithLabelValues("getemployee")))
+	assert.Equal(t, CallStats{Hedges: 1, HedgeWins: 1}, r.stats)
+}
+
+//fakeHrapp serves GetEmployees from employees, calls including a failing id fail
+type fakeHrapp struct {
+	h.HrappClient
+	employees map[int64]*h.Employee
+	failing   map[int64]bool
+	mu        sync.Mutex
+	inFlight  int
+	most      int
+	delay     time.Duration
+	block     chan struct{}
+}
+
+func (f *fakeHrapp) GetEmployees(ctx context.Context, req *h.EmployeeIds, opts ...grpc.CallOption) (*h.EmployeesResponse, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.most {
+		f.most = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(f.delay)
+	if f.block != nil {
+		select {
+		case <-f.block:
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	resp := &h.EmployeesResponse{}
+	for _, id := range req.Ids {
+		if f.failing[id] {
+			return nil, status.Error(codes.Internal, "failed")
+		}
+		if emp, ok := f.employees[id]; ok {
+			resp.Employees = append(resp.Employees, emp)
+		} else {
+			resp.MissingIds = append(resp.MissingIds, id)
+		}
+	}
+	return resp, nil
+}
+
+func TestCrawl(t *testing.T) {
+	employees := map[int64]*h.Employee{1: {Id: 1, Name: "CEO", Reports: []int64{}}}
+	for id := int64(2); id <= 41; id++ {
+		employees[1].Reports = append(employees[1].Reports, id)
+		employees[id] = &h.Employee{Id: id, Name: fmt.Sprint("manager", id), Reports: []int64{id * 100}}
+		employees[id*100] = &h.Employee{Id: id * 100, Name: fmt.Sprint("report", id*100), Reports: []int64{}}
+	}
+	fake := &fakeHrapp{employees: employees, failing: map[int64]bool{500: true}, delay: 5 * time.Millisecond}
+	client := &Client{config: &Config{}, logger: zap.NewNop(), hrapp: fake}
+
+	//the call fetching 500 fails, everything else is returned with the failure
+	ans, err := client.FetchHierarchy(context.Background(), 1, &FetchOptions{Walk: true, Concurrency: 3})
+	crawlErr, ok := err.(*CrawlError)
+	assert.T(t, ok)
+	assert.Equal(t, 1, len(crawlErr.Failures))
+	assert.Equal(t, []int64{500}, crawlErr.Failures[0].Ids)
+	assert.Equal(t, 40, len(ans.Reports))
+	assert.Equal(t, int64(5), ans.Reports[3].Id)
+	assert.Equal(t, 0, len(ans.Reports[3].Reports))
+	assert.Equal(t, int64(600), ans.Reports[4].Reports[0].Id)
+	assert.Equal(t, 3, fake.most)
+
+	//a failing root fails the crawl
+	fake.failing[1] = true
+	ans, err = client.FetchHierarchy(context.Background(), 1, &FetchOptions{Walk: true})
+	assert.Equal(t, (*Hierarchy)(nil), ans)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	delete(fake.failing, 1)
+
+	//the timeout cancels the outstanding calls and stops the crawl
+	fake.block = make(chan struct{})
+	start := time.Now()
+	_, err = client.FetchHierarchy(context.Background(), 1, &FetchOptions{Walk: true, Timeout: 50 * time.Millisecond})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.T(t, time.Since(start) < time.Second)
+}
+
+func BenchmarkFetch(bb *testing.B) {
+	client, err := New(zap.NewNop(), &Config{
+		Address:   *svcAddr,
+		TlsConfig: &grpcserver.TlsConfig{TlsEnabled: *tlsEnabled, CAPath: *caPath, CertPath: *certPath, KeyPath: *keyPath},
+	})
+	if err != nil {
+		bb.Fatal(err)
+	}
+	defer client.Close()
+	for n := 0; n < bb.N; n++ {
+		if _, err := client.FetchHierarchy(context.Background(), 1, &FetchOptions{Walk: true}); err != nil {
+			bb.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package hrappclient
+
+import (
+	"context"
+	"fmt"
+
+	h "github.com/nilangshah/hrapp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//CrawlError lists the GetEmployees calls of a walk that failed or were never made, the hierarchy
+//returned with it lacks their employees and everyone below them
+type CrawlError struct {
+	Failures []*CrawlFailure
+}
+
+//CrawlFailure is a GetEmployees call of a walk that failed
+type CrawlFailure struct {
+	Ids []int64
+	Err error
+}
+
+func (e *CrawlError) Error() string {
+	missing := 0
+	for _, failure := range e.Failures {
+		missing += len(failure.Ids)
+	}
+	return fmt.Sprintf("%d employees not fetched in %d calls, first error: %v", missing, len(e.Failures), e.Failures[0].Err)
+}
+
+//crawlTask is one GetEmployees call of a walk, ids are reports of the same manager at depth
+type crawlTask struct {
+	ids   []int64
+	depth int
+}
+
+type crawlResult struct {
+	task *crawlTask
+	resp *h.EmployeesResponse
+	err  error
+}
+
+//crawl fetches the hierarchy under root with a pool of concurrency workers, each call fetching the
+//reports of one manager, at most MAXBATCHSIZE at a time. Reports deeper than maxDepth levels are not
+//fetched unless it is 0. Failed calls don't stop the crawl, they are returned in a *CrawlError with
+//what was fetched. Once ctx is done no more calls are made and the outstanding ones are cancelled
+func (c *Client) crawl(ctx context.Context, root int64, maxDepth int, concurrency int) (*Hierarchy, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tasks := make(chan *crawlTask)
+	results := make(chan *crawlResult)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for task := range tasks {
+				resp, err := c.GetEmployees(ctx, task.ids)
+				results <- &crawlResult{task: task, resp: resp, err: err}
+			}
+		}()
+	}
+	defer close(tasks)
+
+	employees := map[int64]*h.Employee{}
+	parent := map[int64]int64{}
+	visited := map[int64]bool{root: true}
+	queue := []*crawlTask{{ids: []int64{root}}}
+	crawlErr := &CrawlError{}
+	inFlight := 0
+	for {
+		var send chan<- *crawlTask
+		var next *crawlTask
+		var done <-chan struct{}
+		if len(queue) > 0 && ctx.Err() == nil {
+			send, next, done = tasks, queue[0], ctx.Done()
+		}
+		if send == nil && inFlight == 0 {
+			break
+		}
+		select {
+		case send <- next:
+			queue = queue[1:]
+			inFlight++
+		case <-done:
+		case result := <-results:
+			inFlight--
+			if result.err != nil {
+				c.logger.Warn("gRPCClient: Failed to fetch employees, skipping them", zap.Int64s("ids", result.task.ids), zap.Error(result.err))
+				crawlErr.Failures = append(crawlErr.Failures, &CrawlFailure{Ids: result.task.ids, Err: result.err})
+				continue
+			}
+			if len(result.resp.MissingIds) > 0 {
+				c.logger.Warn("gRPCClient: Employees not found, skipping them", zap.Int64s("ids", result.resp.MissingIds))
+			}
+			depth := result.task.depth + 1
+			for _, emp := range result.resp.Employees {
+				employees[emp.Id] = emp
+				if maxDepth != 0 && depth > maxDepth {
+					continue
+				}
+				reports := []int64{}
+				for _, report := range emp.Reports {
+					if !visited[report] {
+						visited[report] = true
+						parent[report] = emp.Id
+						reports = append(reports, report)
+					}
+				}
+				for start := 0; start < len(reports); start += h.MAXBATCHSIZE {
+					end := start + h.MAXBATCHSIZE
+					if end > len(reports) {
+						end = len(reports)
+					}
+					queue = append(queue, &crawlTask{ids: reports[start:end], depth: depth})
+				}
+			}
+		}
+	}
+	for _, task := range queue {
+		crawlErr.Failures = append(crawlErr.Failures, &CrawlFailure{Ids: task.ids, Err: ctx.Err()})
+	}
+
+	if _, ok := employees[root]; !ok {
+		if len(crawlErr.Failures) > 0 {
+			return nil, crawlErr.Failures[0].Err
+		}
+		return nil, status.Errorf(codes.NotFound, "employee %d not found", root)
+	}
+	ans := buildHierarchy(employees, parent, root)
+	if len(crawlErr.Failures) > 0 {
+		return ans, crawlErr
+	}
+	return ans, nil
+}
+
+//buildHierarchy nests the fetched employees under their manager in the order of its reports
+func buildHierarchy(employees map[int64]*h.Employee, parent map[int64]int64, id int64) *Hierarchy {
+	emp := employees[id]
+	node := &Hierarchy{Id: emp.Id, Name: emp.Name, Title: emp.Title, Reports: []*Hierarchy{}}
+	for _, report := range emp.Reports {
+		if _, ok := employees[report]; ok && parent[report] == id {
+			node.Reports = append(node.Reports, buildHierarchy(employees, parent, report))
+		}
+	}
+	return node
+}
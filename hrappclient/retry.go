@@ -0,0 +1,217 @@
+package hrappclient
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/nilangshah/hrapp/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	//Default longest wait between two retries
+	DEFAULTMAXRETRYBACKOFF = 2 * time.Second
+)
+
+var (
+	clientRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_client_retries_total",
+			Help: "How many hrapp calls retried, partitioned by method and the gRPC status code of the failed attempt.",
+		},
+		[]string{"method", "code"},
+	)
+	clientHedges = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_client_hedges_total",
+			Help: "How many hedged hrapp requests sent, partitioned by method.",
+		},
+		[]string{"method"},
+	)
+	clientHedgeWins = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "grpc_client_hedge_wins_total",
+			Help: "How many hrapp calls answered by a hedged request before the first one, partitioned by method.",
+		},
+		[]string{"method"},
+	)
+	registerMetrics sync.Once
+)
+
+//idempotentMethods are the calls safe to send more than once
+var idempotentMethods = map[string]bool{
+	"/hrapp/getEmployee":      true,
+	"/hrapp/getEmployees":     true,
+	"/hrapp/getReportingTree": true,
+	"/hrapp/getManagerChain":  true,
+	"/hrapp/listEmployees":    true,
+	"/hrapp/searchEmployees":  true,
+}
+
+//retrier applies the timeout, retry and hedging settings of a Config to unary calls
+type retrier struct {
+	callTimeout time.Duration
+	retries     int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	hedgeDelay  time.Duration
+	maxHedges   int
+	logger      *zap.Logger
+	stats       CallStats
+}
+
+//CallStats counts the retries and hedged requests of one Client, the same counts are exported as the
+//grpc_client_* metrics of the default prometheus registry, summed over all clients
+type CallStats struct {
+	Retries   int64
+	Hedges    int64
+	HedgeWins int64
+}
+
+func newRetrier(logger *zap.Logger, config *Config) *retrier {
+	r := &retrier{
+		callTimeout: config.CallTimeout,
+		retries:     config.Retries,
+		backoff:     config.RetryBackoff,
+		maxBackoff:  config.MaxRetryBackoff,
+		hedgeDelay:  config.HedgeDelay,
+		maxHedges:   config.MaxHedges,
+		logger:      logger,
+	}
+	if r.backoff <= 0 {
+		r.backoff = DEFAULTRETRYBACKOFF
+	}
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = DEFAULTMAXRETRYBACKOFF
+	}
+	if r.hedgeDelay > 0 && r.maxHedges <= 0 {
+		r.maxHedges = 1
+	}
+	return r
+}
+
+//unaryInterceptor bounds every attempt with the call timeout. Idempotent calls failing with UNAVAILABLE,
+//or running out of the call timeout, are retried after an exponential backoff with jitter, and with a
+//hedge delay more requests are sent while the first one is outstanding, the first answer wins
+func (r *retrier) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !idempotentMethods[method] {
+		return r.attempt(ctx, method, req, reply, cc, invoker, opts...)
+	}
+	backoff := r.backoff
+	for retry := 0; ; retry++ {
+		var err error
+		if r.hedgeDelay > 0 {
+			err = r.hedged(ctx, method, req, reply, cc, invoker, opts...)
+		} else {
+			err = r.attempt(ctx, method, req, reply, cc, invoker, opts...)
+		}
+		if err == nil || retry >= r.retries || !retryable(ctx, err) {
+			return err
+		}
+		clientRetries.WithLabelValues(util.MethodName(method), status.Code(err).String()).Inc()
+		atomic.AddInt64(&r.stats.Retries, 1)
+		//wait between half and all of the backoff so that clients failing together don't retry together
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		r.logger.Warn("gRPCClient: Call failed, retrying", zap.String("method", method), zap.Int("retry", retry+1), zap.Duration("backoff", wait), zap.Error(err))
+		if sleep(ctx, wait) != nil {
+			return err
+		}
+		if backoff *= 2; backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+//hedged sends the call, and another one every hedge delay while none has answered, up to maxHedges more.
+//The first success or non retryable error is returned, the other calls are cancelled. If all calls fail
+//with retryable errors the last error is returned
+func (r *retrier) hedged(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		reply proto.Message
+		err   error
+		hedge bool
+	}
+	results := make(chan *result, r.maxHedges+1)
+	send := func(hedge bool) {
+		res := &result{reply: proto.Clone(reply.(proto.Message)), hedge: hedge}
+		go func() {
+			res.err = r.attempt(ctx, method, req, res.reply, cc, invoker, opts...)
+			results <- res
+		}()
+	}
+	send(false)
+	sent, failed := 1, 0
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if sent <= r.maxHedges {
+				clientHedges.WithLabelValues(util.MethodName(method)).Inc()
+				atomic.AddInt64(&r.stats.Hedges, 1)
+				send(true)
+				sent++
+				timer.Reset(r.hedgeDelay)
+			}
+		case res := <-results:
+			if res.err != nil && retryable(ctx, res.err) {
+				if failed++; failed < sent {
+					continue
+				}
+				return res.err
+			}
+			if res.err == nil {
+				if res.hedge {
+					clientHedgeWins.WithLabelValues(util.MethodName(method)).Inc()
+					atomic.AddInt64(&r.stats.HedgeWins, 1)
+				}
+				reply.(proto.Message).Reset()
+				proto.Merge(reply.(proto.Message), res.reply)
+			}
+			return res.err
+		}
+	}
+}
+
+//attempt makes one call bounded by the call timeout
+func (r *retrier) attempt(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if r.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.callTimeout)
+		defer cancel()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+//retryable reports whether a call that failed with err may succeed when sent again, running out of
+//time only counts when ctx itself still has time left
+func retryable(ctx context.Context, err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return ctx.Err() == nil
+	default:
+		return false
+	}
+}
+
+//sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
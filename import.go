@@ -0,0 +1,284 @@
+package hrapp
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	//Most employees accepted by a single import
+	MAXIMPORTSIZE = 100000
+	//KEEPMANAGER as the manager_id of an imported employee keeps its stored manager, new employees get none
+	KEEPMANAGER = -1
+)
+
+//ImportPlan is the outcome of an import, the rows it creates and the rows it changes
+type ImportPlan struct {
+	Creates []*Employee
+	Updates []*EmployeeChange
+}
+
+//hierarchyNode is the EmpHierarchy JSON printed by the client, WriteOrg also uses it for YAML
+type hierarchyNode struct {
+	Id      int64            `json:"id" yaml:"id"`
+	Name    string           `json:"name" yaml:"name"`
+	Title   string           `json:"title" yaml:"title"`
+	Reports []*hierarchyNode `json:"reports" yaml:"reports"`
+}
+
+//ParseEmployeesCSV reads id,name,title,manager_id records, an optional first record naming the columns is skipped
+func ParseEmployeesCSV(r io.Reader) ([]*Employee, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	employees := []*Employee{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return employees, nil
+		} else if err != nil {
+			return nil, errors.Wrap(ErrInvalidImport, err.Error())
+		}
+		if line == 1 && strings.EqualFold(record[0], "id") {
+			continue
+		}
+		id, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidImport, "line %d: invalid id %q", line, record[0])
+		}
+		managerId := int64(0)
+		if record[3] != "" {
+			if managerId, err = strconv.ParseInt(record[3], 10, 64); err != nil {
+				return nil, errors.Wrapf(ErrInvalidImport, "line %d: invalid manager_id %q", line, record[3])
+			}
+		}
+		employees = append(employees, &Employee{Id: id, Name: record[1], Title: record[2], ManagerId: managerId})
+	}
+}
+
+//ParseHierarchyJSON reads one EmpHierarchy tree, or an array of them, as printed by the client. The
+//manager of every employee is the node it is nested in, tree roots keep their stored manager
+func ParseHierarchyJSON(r io.Reader) ([]*Employee, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var roots []*hierarchyNode
+	if trimmed := strings.TrimSpace(string(b)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(b, &roots)
+	} else {
+		root := &hierarchyNode{}
+		err = json.Unmarshal(b, root)
+		roots = []*hierarchyNode{root}
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidImport, err.Error())
+	}
+	employees := []*Employee{}
+	var flatten func(node *hierarchyNode, managerId int64)
+	flatten = func(node *hierarchyNode, managerId int64) {
+		employees = append(employees, &Employee{Id: node.Id, Name: node.Name, Title: node.Title, ManagerId: managerId})
+		for _, report := range node.Reports {
+			flatten(report, node.Id)
+		}
+	}
+	for _, root := range roots {
+		flatten(root, KEEPMANAGER)
+	}
+	return employees, nil
+}
+
+//ImportOrg creates the employees of incoming that don't exist and updates name, title and manager
+//of those that do. The whole result is validated before anything is written: ids must be positive and
+//unique, names set, managers must exist in the store or in incoming, and manager links can't loop.
+//Rows are then written with PutEmployees, MAXPUTBATCH rows at a time, unless dryRun is set. A failed write
+//keeps the rows written before it and its error tells how many they are. Writes made to the store while
+//the import runs can be overwritten
+func ImportOrg(store EmployeeStore, incoming []*Employee, dryRun bool) (*ImportPlan, error) {
+	if len(incoming) > MAXIMPORTSIZE {
+		return nil, errors.Wrapf(ErrInvalidImport, "at most %d employees per import, got %d", MAXIMPORTSIZE, len(incoming))
+	}
+	existing := map[int64]*Employee{}
+	err := ForEachEmployee(store, &EmployeeFilter{}, MAXPAGESIZE, func(emp *Employee) error {
+		existing[emp.Id] = emp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan, err := PlanImport(existing, incoming)
+	if err != nil || dryRun {
+		return plan, err
+	}
+	rows := plan.Rows()
+	for start := 0; start < len(rows); start += MAXPUTBATCH {
+		end := start + MAXPUTBATCH
+		if end > len(rows) {
+			end = len(rows)
+		}
+		if err := store.PutEmployees(rows[start:end]); err != nil {
+			return nil, errors.Wrapf(err, "%d of %d rows were written before the failure", start, len(rows))
+		}
+	}
+	return plan, nil
+}
+
+//PlanImport validates incoming against the existing employees and returns the rows the import writes
+func PlanImport(existing map[int64]*Employee, incoming []*Employee) (*ImportPlan, error) {
+	final := make(map[int64]*Employee, len(existing)+len(incoming))
+	for id, emp := range existing {
+		final[id] = copyEmployee(emp)
+	}
+	seen := map[int64]bool{}
+	for _, emp := range incoming {
+		switch {
+		case emp.Id <= 0:
+			return nil, errors.Wrapf(ErrInvalidImport, "invalid employee id %d", emp.Id)
+		case seen[emp.Id]:
+			return nil, errors.Wrapf(ErrInvalidImport, "employee %d imported twice", emp.Id)
+		case strings.TrimSpace(emp.Name) == "":
+			return nil, errors.Wrapf(ErrInvalidImport, "employee %d has no name", emp.Id)
+		case emp.ManagerId == emp.Id:
+			return nil, errors.Wrapf(ErrInvalidImport, "employee %d can't manage itself", emp.Id)
+		case emp.ManagerId < KEEPMANAGER:
+			return nil, errors.Wrapf(ErrInvalidImport, "invalid manager_id %d of employee %d", emp.ManagerId, emp.Id)
+		}
+		seen[emp.Id] = true
+		if _, ok := final[emp.Id]; !ok {
+			final[emp.Id] = &Employee{Id: emp.Id, Reports: []int64{}}
+		}
+	}
+	for _, emp := range incoming {
+		if emp.ManagerId == KEEPMANAGER {
+			emp = &Employee{Id: emp.Id, Name: emp.Name, Title: emp.Title, ManagerId: final[emp.Id].ManagerId}
+		}
+		if _, ok := final[emp.ManagerId]; emp.ManagerId != 0 && !ok {
+			return nil, errors.Wrapf(ErrInvalidImport, "manager %d of employee %d doesn't exist", emp.ManagerId, emp.Id)
+		}
+		row := final[emp.Id]
+		if row.ManagerId != emp.ManagerId {
+			if old, ok := final[row.ManagerId]; ok {
+				old.Reports = removeId(old.Reports, emp.Id)
+			}
+		}
+		if manager, ok := final[emp.ManagerId]; ok && !containsId(manager.Reports, emp.Id) {
+			manager.Reports = append(manager.Reports, emp.Id)
+		}
+		row.Name, row.Title, row.ManagerId = emp.Name, emp.Title, emp.ManagerId
+	}
+	for _, emp := range incoming {
+		visited := map[int64]bool{emp.Id: true}
+		for managerId := final[emp.Id].ManagerId; managerId != 0; managerId = final[managerId].ManagerId {
+			if visited[managerId] {
+				return nil, errors.Wrapf(ErrInvalidImport, "manager links of employee %d loop through %d", emp.Id, managerId)
+			}
+			visited[managerId] = true
+			if _, ok := final[managerId]; !ok {
+				break
+			}
+		}
+	}
+	plan := &ImportPlan{Creates: []*Employee{}, Updates: []*EmployeeChange{}}
+	for _, emp := range sortedEmployees(final) {
+		before, ok := existing[emp.Id]
+		switch {
+		case !ok:
+			plan.Creates = append(plan.Creates, emp)
+		case !sameEmployee(before, emp):
+			plan.Updates = append(plan.Updates, &EmployeeChange{Before: before, After: emp})
+		}
+	}
+	return plan, nil
+}
+
+//ImportEmployees reads the employees streamed by the client and imports them all at once when the stream ends
+func (s *ServiceImpl) ImportEmployees(stream Hrapp_ImportEmployeesServer) error {
+	incoming := []*Employee{}
+	dryRun := false
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		dryRun = dryRun || req.DryRun
+		incoming = append(incoming, req.Employees...)
+		if len(incoming) > MAXIMPORTSIZE {
+			return status.Errorf(codes.InvalidArgument, "at most %d employees per import", MAXIMPORTSIZE)
+		}
+	}
+	s.logger.Info("gRPC: ImportEmployees received employees", zap.Int("count", len(incoming)), zap.Bool("dryRun", dryRun))
+	plan, err := ImportOrg(s.empStore, incoming, dryRun)
+	if err != nil {
+		return toStatusError(err)
+	}
+	s.logger.Info("gRPC: ImportEmployees done", zap.Int("created", len(plan.Creates)), zap.Int("updated", len(plan.Updates)), zap.Bool("dryRun", dryRun))
+	return stream.SendAndClose(&ImportEmployeesResponse{Created: plan.Creates, Updated: plan.Updates, DryRun: dryRun})
+}
+
+//Rows returns the rows the plan writes
+func (p *ImportPlan) Rows() []*Employee {
+	rows := make([]*Employee, 0, len(p.Creates)+len(p.Updates))
+	rows = append(rows, p.Creates...)
+	for _, change := range p.Updates {
+		rows = append(rows, change.After)
+	}
+	return rows
+}
+
+//WriteDiff prints one line per created employee, prefixed with +, and one line per changed field of the
+//updated ones, prefixed with ~
+func (p *ImportPlan) WriteDiff(w io.Writer) error {
+	for _, emp := range p.Creates {
+		if _, err := fmt.Fprintf(w, "+ %d name=%q title=%q manager_id=%d reports=%v\n", emp.Id, emp.Name, emp.Title, emp.ManagerId, emp.Reports); err != nil {
+			return err
+		}
+	}
+	for _, change := range p.Updates {
+		before, after := change.Before, change.After
+		fields := []struct {
+			name          string
+			before, after interface{}
+		}{
+			{"name", strconv.Quote(before.Name), strconv.Quote(after.Name)},
+			{"title", strconv.Quote(before.Title), strconv.Quote(after.Title)},
+			{"manager_id", before.ManagerId, after.ManagerId},
+			{"reports", before.Reports, after.Reports},
+		}
+		for _, field := range fields {
+			if fmt.Sprint(field.before) == fmt.Sprint(field.after) {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "~ %d %s: %v -> %v\n", after.Id, field.name, field.before, field.after); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d to create, %d to update\n", len(p.Creates), len(p.Updates))
+	return err
+}
+
+func sameEmployee(a *Employee, b *Employee) bool {
+	return a.Name == b.Name && a.Title == b.Title && a.ManagerId == b.ManagerId && reflect.DeepEqual(append([]int64{}, a.Reports...), append([]int64{}, b.Reports...))
+}
+
+func containsId(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
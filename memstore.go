@@ -0,0 +1,313 @@
+package hrapp
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+//memorystore keeps employees in a map guarded by a RWMutex, it needs no database
+//and is meant for local development and integration tests
+type memorystore struct {
+	mu        sync.RWMutex
+	employees map[int64]*Employee
+	search    *searchIndex
+	logger    *zap.Logger
+}
+
+//MemoryStoreInit creates an in-memory store, seeded from a JSON array of employees when seedFile is set
+func MemoryStoreInit(logger *zap.Logger, seedFile string) (EmployeeStore, error) {
+	logger.Info("DataAccess: Initializing in-memory employee store", zap.String("seedFile", seedFile))
+	var employees []*Employee
+	if seedFile != "" {
+		b, err := ioutil.ReadFile(seedFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "DataAccess: Failed to read seed file")
+		}
+		if err := json.Unmarshal(b, &employees); err != nil {
+			return nil, errors.Wrap(err, "DataAccess: Failed to parse seed file")
+		}
+	}
+	store := NewMemoryStore(logger, employees)
+	logger.Info("DataAccess: In-memory employee store initialized", zap.Int("employees", len(employees)))
+	return store, nil
+}
+
+//NewMemoryStore creates an in-memory store holding copies of employees as they are,
+//the hierarchy is not validated so seeds can contain the same inconsistencies as a database
+func NewMemoryStore(logger *zap.Logger, employees []*Employee) EmployeeStore {
+	store := &memorystore{employees: make(map[int64]*Employee, len(employees)), search: newSearchIndex(), logger: logger}
+	for _, emp := range employees {
+		store.employees[emp.Id] = copyEmployee(emp)
+	}
+	store.search.reset(employees)
+	return store
+}
+
+func (m *memorystore) GetEmployee(id *EmployeeId) (*Employee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	emp, ok := m.employees[id.Id]
+	if !ok {
+		return nil, errors.Wrapf(ErrEmployeeNotFound, "employee %d", id.Id)
+	}
+	return copyEmployee(emp), nil
+}
+
+func (m *memorystore) GetEmployees(ids []int64) ([]*Employee, []int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids = uniqueIds(ids)
+	found := make(map[int64]*Employee, len(ids))
+	for _, id := range ids {
+		if emp, ok := m.employees[id]; ok {
+			found[id] = copyEmployee(emp)
+		}
+	}
+	employees, missing := orderEmployees(ids, found)
+	return employees, missing, nil
+}
+
+func (m *memorystore) CreateEmployee(emp *Employee, managerId int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkCreate(emp, managerId); err != nil {
+		return err
+	}
+	manager := m.employees[managerId]
+	m.employees[emp.Id] = &Employee{Id: emp.Id, Name: emp.Name, Title: emp.Title, Reports: []int64{}, ManagerId: managerId}
+	m.search.put(emp)
+	if manager != nil {
+		manager.Reports = append(manager.Reports, emp.Id)
+	}
+	return nil
+}
+
+func (m *memorystore) UpdateEmployee(emp *Employee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkUpdate(emp); err != nil {
+		return err
+	}
+	stored := m.employees[emp.Id]
+	stored.Name = emp.Name
+	stored.Title = emp.Title
+	m.search.put(stored)
+	return nil
+}
+
+func (m *memorystore) DeleteEmployee(id *EmployeeId) (*Employee, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkDelete(id.Id); err != nil {
+		return nil, err
+	}
+	emp := m.employees[id.Id]
+	if manager, ok := m.employees[emp.ManagerId]; ok {
+		manager.Reports = removeId(manager.Reports, id.Id)
+	}
+	delete(m.employees, id.Id)
+	m.search.delete(id.Id)
+	return emp, nil
+}
+
+func (m *memorystore) MoveEmployee(id int64, newManagerId int64, moveReports bool) (*Employee, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkMove(id, newManagerId, moveReports); err != nil {
+		return nil, err
+	}
+	emp, newManager := m.employees[id], m.employees[newManagerId]
+	if emp.ManagerId == newManagerId {
+		return copyEmployee(emp), nil
+	}
+	oldManager := m.employees[emp.ManagerId]
+	if oldManager != nil {
+		oldManager.Reports = removeId(oldManager.Reports, id)
+	}
+	if newManager != nil {
+		newManager.Reports = append(newManager.Reports, id)
+	}
+	if !moveReports {
+		for _, reportId := range emp.Reports {
+			if report, ok := m.employees[reportId]; ok {
+				report.ManagerId = emp.ManagerId
+			}
+		}
+		if oldManager != nil {
+			oldManager.Reports = append(oldManager.Reports, emp.Reports...)
+		}
+		emp.Reports = []int64{}
+	}
+	emp.ManagerId = newManagerId
+	return copyEmployee(emp), nil
+}
+
+//checkCreate returns the error CreateEmployee fails with, if any, m.mu must be held
+func (m *memorystore) checkCreate(emp *Employee, managerId int64) error {
+	if _, ok := m.employees[managerId]; managerId != 0 && !ok {
+		return errors.Wrapf(ErrEmployeeNotFound, "manager %d", managerId)
+	}
+	if _, ok := m.employees[emp.Id]; ok {
+		return errors.Wrapf(ErrEmployeeExists, "employee %d", emp.Id)
+	}
+	return nil
+}
+
+//checkUpdate returns the error UpdateEmployee fails with, if any, m.mu must be held
+func (m *memorystore) checkUpdate(emp *Employee) error {
+	if _, ok := m.employees[emp.Id]; !ok {
+		return errors.Wrapf(ErrEmployeeNotFound, "employee %d", emp.Id)
+	}
+	return nil
+}
+
+//checkDelete returns the error DeleteEmployee fails with, if any, m.mu must be held
+func (m *memorystore) checkDelete(id int64) error {
+	emp, ok := m.employees[id]
+	if !ok {
+		return errors.Wrapf(ErrEmployeeNotFound, "employee %d", id)
+	}
+	if len(emp.Reports) > 0 {
+		return errors.Wrapf(ErrEmployeeHasReports, "employee %d", id)
+	}
+	return nil
+}
+
+//checkMove returns the error MoveEmployee fails with, if any, m.mu must be held
+func (m *memorystore) checkMove(id int64, newManagerId int64, moveReports bool) error {
+	emp, ok := m.employees[id]
+	if !ok {
+		return errors.Wrapf(ErrEmployeeNotFound, "employee %d", id)
+	}
+	if newManagerId == id {
+		return errors.Wrapf(ErrHierarchyCycle, "employee %d can't manage itself", id)
+	}
+	if err := checkRootMove(emp, newManagerId, moveReports); err != nil {
+		return err
+	}
+	newManager, ok := m.employees[newManagerId]
+	if newManagerId != 0 && !ok {
+		return errors.Wrapf(ErrEmployeeNotFound, "manager %d", newManagerId)
+	}
+	if moveReports {
+		visited := map[int64]bool{}
+		for manager := newManager; manager != nil && !visited[manager.Id]; manager = m.employees[manager.ManagerId] {
+			if manager.Id == id {
+				return errors.Wrapf(ErrHierarchyCycle, "employee %d manages %d", id, newManagerId)
+			}
+			visited[manager.Id] = true
+		}
+	}
+	return nil
+}
+
+func (m *memorystore) PutEmployees(employees []*Employee) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, emp := range employees {
+		m.employees[emp.Id] = copyEmployee(emp)
+		m.search.put(emp)
+	}
+	return nil
+}
+
+func (m *memorystore) GetManagerChain(id *EmployeeId) ([]*Employee, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	emp, ok := m.employees[id.Id]
+	if !ok {
+		return nil, errors.Wrapf(ErrEmployeeNotFound, "employee %d", id.Id)
+	}
+	chain := []*Employee{}
+	visited := map[int64]bool{id.Id: true}
+	for emp.ManagerId != 0 && !visited[emp.ManagerId] {
+		visited[emp.ManagerId] = true
+		manager, ok := m.employees[emp.ManagerId]
+		if !ok {
+			m.logger.Warn("MemoryStore: Manager not found, chain is incomplete", zap.Int64("empId", id.Id), zap.Int64("managerId", emp.ManagerId))
+			break
+		}
+		chain = append(chain, copyEmployee(manager))
+		emp = manager
+	}
+	return chain, nil
+}
+
+//ListEmployees pages through employees in id order, the page state is the last id of the previous page.
+//Non-positive page sizes list DEFAULTPAGESIZE employees
+func (m *memorystore) ListEmployees(filter *EmployeeFilter, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	if pageSize <= 0 {
+		pageSize = DEFAULTPAGESIZE
+	}
+	var after int64
+	if len(pageState) > 0 {
+		id, err := strconv.ParseInt(string(pageState), 10, 64)
+		if err != nil {
+			return nil, nil, errors.Wrapf(ErrInvalidPageToken, "page state %q", pageState)
+		}
+		after = id
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := []int64{}
+	for id, emp := range m.employees {
+		if (len(pageState) == 0 || id > after) && filter.Match(emp) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var next []byte
+	if len(ids) > pageSize {
+		ids = ids[:pageSize]
+		next = []byte(strconv.FormatInt(ids[pageSize-1], 10))
+	}
+	employees := make([]*Employee, len(ids))
+	for i, id := range ids {
+		employees[i] = copyEmployee(m.employees[id])
+	}
+	return employees, next, nil
+}
+
+func (m *memorystore) SearchEmployees(query *SearchQuery, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	ids, next, err := searchPage(m.search.search(query), pageSize, pageState)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	employees := make([]*Employee, 0, len(ids))
+	for _, id := range ids {
+		if emp, ok := m.employees[id]; ok {
+			employees = append(employees, copyEmployee(emp))
+		}
+	}
+	return employees, next, nil
+}
+
+func (m *memorystore) Close() {
+	m.logger.Info("MemoryStore: Closing in-memory employee store")
+}
+
+//copyEmployee returns a copy sharing no slices with emp
+func copyEmployee(emp *Employee) *Employee {
+	reports := make([]int64, len(emp.Reports))
+	copy(reports, emp.Reports)
+	return &Employee{Id: emp.Id, Name: emp.Name, Title: emp.Title, Reports: reports, ManagerId: emp.ManagerId}
+}
+
+//removeId returns ids without any occurrence of id
+func removeId(ids []int64, id int64) []int64 {
+	kept := ids[:0]
+	for _, i := range ids {
+		if i != id {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
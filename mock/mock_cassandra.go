@@ -5,8 +5,9 @@
 package mock
 
 import (
+	gocql "github.com/gocql/gocql"
 	gomock "github.com/golang/mock/gomock"
-	"github.com/nilangshah/hrapp/cassandra"
+	cassandra "github.com/nilangshah/hrapp/cassandra"
 	reflect "reflect"
 )
 
@@ -35,6 +36,7 @@ func (m *MockSessionInterface) EXPECT() *MockSessionInterfaceMockRecorder {
 
 // Query mocks base method
 func (m *MockSessionInterface) Query(arg0 string, arg1 ...interface{}) cassandra.QueryInterface {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{arg0}
 	for _, a := range arg1 {
 		varargs = append(varargs, a)
@@ -46,32 +48,64 @@ func (m *MockSessionInterface) Query(arg0 string, arg1 ...interface{}) cassandra
 
 // Query indicates an expected call of Query
 func (mr *MockSessionInterfaceMockRecorder) Query(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{arg0}, arg1...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockSessionInterface)(nil).Query), varargs...)
 }
 
+// Batch mocks base method
+func (m *MockSessionInterface) Batch(arg0 gocql.BatchType) cassandra.BatchInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Batch", arg0)
+	ret0, _ := ret[0].(cassandra.BatchInterface)
+	return ret0
+}
+
+// Batch indicates an expected call of Batch
+func (mr *MockSessionInterfaceMockRecorder) Batch(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Batch", reflect.TypeOf((*MockSessionInterface)(nil).Batch), arg0)
+}
+
 // SetPageSize mocks base method
 func (m *MockSessionInterface) SetPageSize(arg0 int) {
+	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "SetPageSize", arg0)
 }
 
 // SetPageSize indicates an expected call of SetPageSize
 func (mr *MockSessionInterfaceMockRecorder) SetPageSize(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPageSize", reflect.TypeOf((*MockSessionInterface)(nil).SetPageSize), arg0)
 }
 
+// SetConsistency mocks base method
+func (m *MockSessionInterface) SetConsistency(arg0 gocql.Consistency) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetConsistency", arg0)
+}
+
+// SetConsistency indicates an expected call of SetConsistency
+func (mr *MockSessionInterfaceMockRecorder) SetConsistency(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConsistency", reflect.TypeOf((*MockSessionInterface)(nil).SetConsistency), arg0)
+}
+
 // Close mocks base method
 func (m *MockSessionInterface) Close() {
+	m.ctrl.T.Helper()
 	m.ctrl.Call(m, "Close")
 }
 
 // Close indicates an expected call of Close
 func (mr *MockSessionInterfaceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSessionInterface)(nil).Close))
 }
 
 // Health mocks base method
 func (m *MockSessionInterface) Health() bool {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Health")
 	ret0, _ := ret[0].(bool)
 	return ret0
@@ -79,6 +113,7 @@ func (m *MockSessionInterface) Health() bool {
 
 // Health indicates an expected call of Health
 func (mr *MockSessionInterfaceMockRecorder) Health() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockSessionInterface)(nil).Health))
 }
 
@@ -107,6 +142,7 @@ func (m *MockQueryInterface) EXPECT() *MockQueryInterfaceMockRecorder {
 
 // Bind mocks base method
 func (m *MockQueryInterface) Bind(arg0 ...interface{}) cassandra.QueryInterface {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range arg0 {
 		varargs = append(varargs, a)
@@ -118,11 +154,13 @@ func (m *MockQueryInterface) Bind(arg0 ...interface{}) cassandra.QueryInterface
 
 // Bind indicates an expected call of Bind
 func (mr *MockQueryInterfaceMockRecorder) Bind(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Bind", reflect.TypeOf((*MockQueryInterface)(nil).Bind), arg0...)
 }
 
 // Exec mocks base method
 func (m *MockQueryInterface) Exec() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Exec")
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -130,11 +168,13 @@ func (m *MockQueryInterface) Exec() error {
 
 // Exec indicates an expected call of Exec
 func (mr *MockQueryInterfaceMockRecorder) Exec() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockQueryInterface)(nil).Exec))
 }
 
 // Iter mocks base method
 func (m *MockQueryInterface) Iter() cassandra.IterInterface {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Iter")
 	ret0, _ := ret[0].(cassandra.IterInterface)
 	return ret0
@@ -142,11 +182,13 @@ func (m *MockQueryInterface) Iter() cassandra.IterInterface {
 
 // Iter indicates an expected call of Iter
 func (mr *MockQueryInterfaceMockRecorder) Iter() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iter", reflect.TypeOf((*MockQueryInterface)(nil).Iter))
 }
 
 // Scan mocks base method
 func (m *MockQueryInterface) Scan(arg0 ...interface{}) error {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range arg0 {
 		varargs = append(varargs, a)
@@ -158,9 +200,38 @@ func (m *MockQueryInterface) Scan(arg0 ...interface{}) error {
 
 // Scan indicates an expected call of Scan
 func (mr *MockQueryInterfaceMockRecorder) Scan(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockQueryInterface)(nil).Scan), arg0...)
 }
 
+// PageSize mocks base method
+func (m *MockQueryInterface) PageSize(arg0 int) cassandra.QueryInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PageSize", arg0)
+	ret0, _ := ret[0].(cassandra.QueryInterface)
+	return ret0
+}
+
+// PageSize indicates an expected call of PageSize
+func (mr *MockQueryInterfaceMockRecorder) PageSize(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PageSize", reflect.TypeOf((*MockQueryInterface)(nil).PageSize), arg0)
+}
+
+// PageState mocks base method
+func (m *MockQueryInterface) PageState(arg0 []byte) cassandra.QueryInterface {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PageState", arg0)
+	ret0, _ := ret[0].(cassandra.QueryInterface)
+	return ret0
+}
+
+// PageState indicates an expected call of PageState
+func (mr *MockQueryInterfaceMockRecorder) PageState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PageState", reflect.TypeOf((*MockQueryInterface)(nil).PageState), arg0)
+}
+
 // MockIterInterface is a mock of IterInterface interface
 type MockIterInterface struct {
 	ctrl     *gomock.Controller
@@ -186,6 +257,7 @@ func (m *MockIterInterface) EXPECT() *MockIterInterfaceMockRecorder {
 
 // Scan mocks base method
 func (m *MockIterInterface) Scan(arg0 ...interface{}) bool {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{}
 	for _, a := range arg0 {
 		varargs = append(varargs, a)
@@ -197,11 +269,13 @@ func (m *MockIterInterface) Scan(arg0 ...interface{}) bool {
 
 // Scan indicates an expected call of Scan
 func (mr *MockIterInterfaceMockRecorder) Scan(arg0 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockIterInterface)(nil).Scan), arg0...)
 }
 
 // Close mocks base method
 func (m *MockIterInterface) Close() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Close")
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -209,9 +283,24 @@ func (m *MockIterInterface) Close() error {
 
 // Close indicates an expected call of Close
 func (mr *MockIterInterfaceMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIterInterface)(nil).Close))
 }
 
+// PageState mocks base method
+func (m *MockIterInterface) PageState() []byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PageState")
+	ret0, _ := ret[0].([]byte)
+	return ret0
+}
+
+// PageState indicates an expected call of PageState
+func (mr *MockIterInterfaceMockRecorder) PageState() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PageState", reflect.TypeOf((*MockIterInterface)(nil).PageState))
+}
+
 // MockBatchInterface is a mock of BatchInterface interface
 type MockBatchInterface struct {
 	ctrl     *gomock.Controller
@@ -237,6 +326,7 @@ func (m *MockBatchInterface) EXPECT() *MockBatchInterfaceMockRecorder {
 
 // ExecuteBatch mocks base method
 func (m *MockBatchInterface) ExecuteBatch() error {
+	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ExecuteBatch")
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -244,11 +334,13 @@ func (m *MockBatchInterface) ExecuteBatch() error {
 
 // ExecuteBatch indicates an expected call of ExecuteBatch
 func (mr *MockBatchInterfaceMockRecorder) ExecuteBatch() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteBatch", reflect.TypeOf((*MockBatchInterface)(nil).ExecuteBatch))
 }
 
 // Query mocks base method
 func (m *MockBatchInterface) Query(stmt string, args ...interface{}) {
+	m.ctrl.T.Helper()
 	varargs := []interface{}{stmt}
 	for _, a := range args {
 		varargs = append(varargs, a)
@@ -258,6 +350,7 @@ func (m *MockBatchInterface) Query(stmt string, args ...interface{}) {
 
 // Query indicates an expected call of Query
 func (mr *MockBatchInterfaceMockRecorder) Query(stmt interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	varargs := append([]interface{}{stmt}, args...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockBatchInterface)(nil).Query), varargs...)
 }
@@ -0,0 +1,195 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: employeestore.go
+
+// Package hrapp is a generated GoMock package.
+package hrapp
+
+import (
+	gomock "github.com/golang/mock/gomock"
+	reflect "reflect"
+)
+
+// MockEmployeeStore is a mock of EmployeeStore interface
+type MockEmployeeStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmployeeStoreMockRecorder
+}
+
+// MockEmployeeStoreMockRecorder is the mock recorder for MockEmployeeStore
+type MockEmployeeStoreMockRecorder struct {
+	mock *MockEmployeeStore
+}
+
+// NewMockEmployeeStore creates a new mock instance
+func NewMockEmployeeStore(ctrl *gomock.Controller) *MockEmployeeStore {
+	mock := &MockEmployeeStore{ctrl: ctrl}
+	mock.recorder = &MockEmployeeStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEmployeeStore) EXPECT() *MockEmployeeStoreMockRecorder {
+	return m.recorder
+}
+
+// GetEmployee mocks base method
+func (m *MockEmployeeStore) GetEmployee(arg0 *EmployeeId) (*Employee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmployee", arg0)
+	ret0, _ := ret[0].(*Employee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmployee indicates an expected call of GetEmployee
+func (mr *MockEmployeeStoreMockRecorder) GetEmployee(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployee", reflect.TypeOf((*MockEmployeeStore)(nil).GetEmployee), arg0)
+}
+
+// GetEmployees mocks base method
+func (m *MockEmployeeStore) GetEmployees(ids []int64) ([]*Employee, []int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEmployees", ids)
+	ret0, _ := ret[0].([]*Employee)
+	ret1, _ := ret[1].([]int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEmployees indicates an expected call of GetEmployees
+func (mr *MockEmployeeStoreMockRecorder) GetEmployees(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployees", reflect.TypeOf((*MockEmployeeStore)(nil).GetEmployees), ids)
+}
+
+// CreateEmployee mocks base method
+func (m *MockEmployeeStore) CreateEmployee(emp *Employee, managerId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEmployee", emp, managerId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateEmployee indicates an expected call of CreateEmployee
+func (mr *MockEmployeeStoreMockRecorder) CreateEmployee(emp, managerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEmployee", reflect.TypeOf((*MockEmployeeStore)(nil).CreateEmployee), emp, managerId)
+}
+
+// UpdateEmployee mocks base method
+func (m *MockEmployeeStore) UpdateEmployee(arg0 *Employee) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmployee", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmployee indicates an expected call of UpdateEmployee
+func (mr *MockEmployeeStoreMockRecorder) UpdateEmployee(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmployee", reflect.TypeOf((*MockEmployeeStore)(nil).UpdateEmployee), arg0)
+}
+
+// DeleteEmployee mocks base method
+func (m *MockEmployeeStore) DeleteEmployee(arg0 *EmployeeId) (*Employee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEmployee", arg0)
+	ret0, _ := ret[0].(*Employee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEmployee indicates an expected call of DeleteEmployee
+func (mr *MockEmployeeStoreMockRecorder) DeleteEmployee(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEmployee", reflect.TypeOf((*MockEmployeeStore)(nil).DeleteEmployee), arg0)
+}
+
+// MoveEmployee mocks base method
+func (m *MockEmployeeStore) MoveEmployee(id, newManagerId int64, moveReports bool) (*Employee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveEmployee", id, newManagerId, moveReports)
+	ret0, _ := ret[0].(*Employee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveEmployee indicates an expected call of MoveEmployee
+func (mr *MockEmployeeStoreMockRecorder) MoveEmployee(id, newManagerId, moveReports interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveEmployee", reflect.TypeOf((*MockEmployeeStore)(nil).MoveEmployee), id, newManagerId, moveReports)
+}
+
+// PutEmployees mocks base method
+func (m *MockEmployeeStore) PutEmployees(arg0 []*Employee) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutEmployees", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutEmployees indicates an expected call of PutEmployees
+func (mr *MockEmployeeStoreMockRecorder) PutEmployees(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutEmployees", reflect.TypeOf((*MockEmployeeStore)(nil).PutEmployees), arg0)
+}
+
+// GetManagerChain mocks base method
+func (m *MockEmployeeStore) GetManagerChain(arg0 *EmployeeId) ([]*Employee, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetManagerChain", arg0)
+	ret0, _ := ret[0].([]*Employee)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetManagerChain indicates an expected call of GetManagerChain
+func (mr *MockEmployeeStoreMockRecorder) GetManagerChain(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetManagerChain", reflect.TypeOf((*MockEmployeeStore)(nil).GetManagerChain), arg0)
+}
+
+// ListEmployees mocks base method
+func (m *MockEmployeeStore) ListEmployees(filter *EmployeeFilter, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEmployees", filter, pageSize, pageState)
+	ret0, _ := ret[0].([]*Employee)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListEmployees indicates an expected call of ListEmployees
+func (mr *MockEmployeeStoreMockRecorder) ListEmployees(filter, pageSize, pageState interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEmployees", reflect.TypeOf((*MockEmployeeStore)(nil).ListEmployees), filter, pageSize, pageState)
+}
+
+// SearchEmployees mocks base method
+func (m *MockEmployeeStore) SearchEmployees(query *SearchQuery, pageSize int, pageState []byte) ([]*Employee, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchEmployees", query, pageSize, pageState)
+	ret0, _ := ret[0].([]*Employee)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchEmployees indicates an expected call of SearchEmployees
+func (mr *MockEmployeeStoreMockRecorder) SearchEmployees(query, pageSize, pageState interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchEmployees", reflect.TypeOf((*MockEmployeeStore)(nil).SearchEmployees), query, pageSize, pageState)
+}
+
+// Close mocks base method
+func (m *MockEmployeeStore) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close
+func (mr *MockEmployeeStoreMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockEmployeeStore)(nil).Close))
+}
@@ -0,0 +1,142 @@
+package hrapp
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	//Upper bound on reporting levels walked for a single request
+	MAXREPORTINGDEPTH = 64
+)
+
+//GetReportingTree builds the reporting hierarchy below the requested employee in a single call
+func (s *ServiceImpl) GetReportingTree(ctx context.Context, req *ReportingTreeRequest) (*ReportingTree, error) {
+	s.logger.Debug("gRPC: GetReportingTree called", zap.Int64("empId", req.Id), zap.Int32("maxDepth", req.MaxDepth))
+	_, tree, err := s.reportingTree(ctx, req.Id, req.MaxDepth)
+	return tree, err
+}
+
+//reportingTree returns the requested employee and the hierarchy below it, errors are gRPC status errors
+func (s *ServiceImpl) reportingTree(ctx context.Context, id int64, maxDepth int32) (*Employee, *ReportingTree, error) {
+	if maxDepth < 0 {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid max depth %d", maxDepth)
+	}
+	root, err := s.empStore.GetEmployee(&EmployeeId{Id: id})
+	if err != nil {
+		return nil, nil, toStatusError(err)
+	}
+	visited := map[int64]bool{root.Id: true}
+	tree := &ReportingTree{Id: root.Id, Name: root.Name, Title: root.Title}
+	if err := s.buildReportingTree(ctx, tree, root.Reports, 1, reportingDepth(maxDepth), visited); err != nil {
+		return nil, nil, err
+	}
+	return root, tree, nil
+}
+
+//buildReportingTree attaches reports to parent depth first, employees already in the tree are skipped
+//so that a cycle in the stored hierarchy can't recurse forever
+func (s *ServiceImpl) buildReportingTree(ctx context.Context, parent *ReportingTree, reports []int64, depth, maxDepth int32, visited map[int64]bool) error {
+	if depth > maxDepth {
+		return nil
+	}
+	parent.Reports = make([]*ReportingTree, 0, len(reports))
+	for _, id := range reports {
+		if err := ctx.Err(); err != nil {
+			return contextError(err)
+		}
+		if visited[id] {
+			s.logger.Warn("ReportingTree: Employee reachable more than once, skipping", zap.Int64("empId", id), zap.Int64("managerId", parent.Id))
+			continue
+		}
+		visited[id] = true
+		emp, err := s.empStore.GetEmployee(&EmployeeId{Id: id})
+		if errors.Cause(err) == ErrEmployeeNotFound {
+			s.logger.Warn("ReportingTree: Report not found, skipping", zap.Int64("empId", id), zap.Int64("managerId", parent.Id))
+			continue
+		} else if err != nil {
+			return toStatusError(err)
+		}
+		node := &ReportingTree{Id: emp.Id, Name: emp.Name, Title: emp.Title}
+		parent.Reports = append(parent.Reports, node)
+		if err := s.buildReportingTree(ctx, node, emp.Reports, depth+1, maxDepth, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//StreamSubtree sends the hierarchy below the requested employee in BFS order, one employee per message,
+//so that large orgs are not limited by the gRPC message size
+func (s *ServiceImpl) StreamSubtree(req *ReportingTreeRequest, stream Hrapp_StreamSubtreeServer) error {
+	s.logger.Debug("gRPC: StreamSubtree called", zap.Int64("empId", req.Id), zap.Int32("maxDepth", req.MaxDepth))
+	if req.MaxDepth < 0 {
+		return status.Errorf(codes.InvalidArgument, "invalid max depth %d", req.MaxDepth)
+	}
+	root, err := s.empStore.GetEmployee(&EmployeeId{Id: req.Id})
+	if err != nil {
+		return toStatusError(err)
+	}
+	maxDepth := reportingDepth(req.MaxDepth)
+	visited := map[int64]bool{root.Id: true}
+	queue := []*SubtreeNode{{Employee: root}}
+	for len(queue) > 0 {
+		if err := stream.Context().Err(); err != nil {
+			return contextError(err)
+		}
+		node := queue[0]
+		queue = queue[1:]
+		if err := stream.Send(node); err != nil {
+			return err
+		}
+		if node.Depth >= maxDepth {
+			continue
+		}
+		for _, id := range node.Employee.Reports {
+			if visited[id] {
+				s.logger.Warn("StreamSubtree: Employee reachable more than once, skipping", zap.Int64("empId", id), zap.Int64("managerId", node.Employee.Id))
+				continue
+			}
+			visited[id] = true
+			emp, err := s.empStore.GetEmployee(&EmployeeId{Id: id})
+			if errors.Cause(err) == ErrEmployeeNotFound {
+				s.logger.Warn("StreamSubtree: Report not found, skipping", zap.Int64("empId", id), zap.Int64("managerId", node.Employee.Id))
+				continue
+			} else if err != nil {
+				return toStatusError(err)
+			}
+			queue = append(queue, &SubtreeNode{Employee: emp, ParentId: node.Employee.Id, Depth: node.Depth + 1})
+		}
+	}
+	return nil
+}
+
+//GetManagerChain returns the managers of the requested employee from the direct manager up to the root
+func (s *ServiceImpl) GetManagerChain(ctx context.Context, id *EmployeeId) (*ManagerChain, error) {
+	s.logger.Debug("gRPC: GetManagerChain called", zap.Int64("empId", id.Id))
+	managers, err := s.empStore.GetManagerChain(id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ManagerChain{Managers: managers}, nil
+}
+
+//reportingDepth caps the requested depth, 0 asks for the whole hierarchy
+func reportingDepth(maxDepth int32) int32 {
+	if maxDepth == 0 || maxDepth > MAXREPORTINGDEPTH {
+		return MAXREPORTINGDEPTH
+	}
+	return maxDepth
+}
+
+//contextError converts a context error into the matching gRPC status
+func contextError(err error) error {
+	if err == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Canceled, err.Error())
+}
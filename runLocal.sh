@@ -1,6 +1,6 @@
 #!/bin/bash
 
-go build -o hrapp cmd/main.go
+go build -o hrapp ./cmd
 go build -o hrappClient client/hrapp.go
 
 ./hrapp > /tmp/hrapp.log 2>&1 &
@@ -0,0 +1,253 @@
+package hrapp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	//Default time after which the cassandra store rebuilds its search index, to pick up writes made by other replicas
+	SEARCHREFRESH = time.Minute
+)
+
+//SearchQuery selects the employees returned by SearchEmployees. Name matches case insensitive
+//anywhere in the name, title matches the whole title case insensitive, empty fields match everyone
+type SearchQuery struct {
+	Name  string
+	Title string
+}
+
+//Ranks of a name match, better matches are listed first
+const (
+	rankExact = iota
+	rankPrefix
+	rankSubstring
+)
+
+//searchPage returns pageSize ids of ranked starting at the offset in pageState, and the state of the next page
+func searchPage(ranked []int64, pageSize int, pageState []byte) ([]int64, []byte, error) {
+	if pageSize <= 0 {
+		pageSize = DEFAULTPAGESIZE
+	}
+	offset := 0
+	if len(pageState) > 0 {
+		o, err := strconv.Atoi(string(pageState))
+		if err != nil || o < 0 {
+			return nil, nil, errors.Wrapf(ErrInvalidPageToken, "page state %q", pageState)
+		}
+		offset = o
+	}
+	if offset >= len(ranked) {
+		return []int64{}, nil, nil
+	}
+	end := offset + pageSize
+	if end >= len(ranked) {
+		return ranked[offset:], nil, nil
+	}
+	return ranked[offset:end], []byte(strconv.Itoa(end)), nil
+}
+
+//searchIndex finds employees by name and title. Names are split into trigrams so substring
+//queries only look at employees sharing every trigram of the query, titles map to their employees
+type searchIndex struct {
+	mu       sync.RWMutex
+	names    map[int64]string
+	titles   map[int64]string
+	byTitle  map[string]map[int64]bool
+	trigrams map[string]map[int64]bool
+	built    time.Time
+	//changes are the puts and deletes made since startRebuild, reset applies them again to the new index
+	rebuilding bool
+	changes    []searchChange
+}
+
+//searchChange is a put of emp, or a delete of id when emp is nil
+type searchChange struct {
+	id  int64
+	emp *Employee
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		names:    map[int64]string{},
+		titles:   map[int64]string{},
+		byTitle:  map[string]map[int64]bool{},
+		trigrams: map[string]map[int64]bool{},
+	}
+}
+
+//put indexes emp, replacing what was indexed for its id before
+func (x *searchIndex) put(emp *Employee) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(emp.Id)
+	name, title := strings.ToLower(emp.Name), strings.ToLower(emp.Title)
+	x.names[emp.Id] = name
+	x.titles[emp.Id] = title
+	addPosting(x.byTitle, title, emp.Id)
+	for _, t := range trigrams(name) {
+		addPosting(x.trigrams, t, emp.Id)
+	}
+	if x.rebuilding {
+		x.changes = append(x.changes, searchChange{emp.Id, &Employee{Id: emp.Id, Name: emp.Name, Title: emp.Title}})
+	}
+}
+
+//delete drops id from the index
+func (x *searchIndex) delete(id int64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.remove(id)
+	if x.rebuilding {
+		x.changes = append(x.changes, searchChange{id: id})
+	}
+}
+
+//startRebuild records the changes made from now on, until reset or abortRebuild, so that the employees
+//read for a rebuild don't overwrite writes made while they were read
+func (x *searchIndex) startRebuild() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.rebuilding, x.changes = true, nil
+}
+
+//abortRebuild stops recording changes, the index in use stays
+func (x *searchIndex) abortRebuild() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.rebuilding, x.changes = false, nil
+}
+
+//reset replaces the whole index with employees and the changes recorded since startRebuild
+func (x *searchIndex) reset(employees []*Employee) {
+	fresh := newSearchIndex()
+	for _, emp := range employees {
+		fresh.put(emp)
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, change := range x.changes {
+		if change.emp == nil {
+			fresh.delete(change.id)
+		} else {
+			fresh.put(change.emp)
+		}
+	}
+	x.names, x.titles, x.byTitle, x.trigrams = fresh.names, fresh.titles, fresh.byTitle, fresh.trigrams
+	x.built = time.Now()
+	x.rebuilding, x.changes = false, nil
+}
+
+//age returns how long ago the index was last reset, ok is false if it never was
+func (x *searchIndex) age() (time.Duration, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return time.Since(x.built), !x.built.IsZero()
+}
+
+//search returns the ids matching query, exact name matches first, then prefix and then substring
+//matches, each ordered by name and id
+func (x *searchIndex) search(query *SearchQuery) []int64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	name, title := strings.ToLower(query.Name), strings.ToLower(query.Title)
+	//start from the smallest posting list, every match is in all of them
+	all := true
+	var candidates map[int64]bool
+	if title != "" {
+		candidates, all = x.byTitle[title], false
+	}
+	for _, t := range trigrams(name) {
+		if all || len(x.trigrams[t]) < len(candidates) {
+			candidates, all = x.trigrams[t], false
+		}
+	}
+	type match struct {
+		id   int64
+		rank int
+	}
+	matches := []match{}
+	consider := func(id int64) {
+		if title != "" && x.titles[id] != title {
+			return
+		}
+		switch n := x.names[id]; {
+		case n == name:
+			matches = append(matches, match{id, rankExact})
+		case strings.HasPrefix(n, name):
+			matches = append(matches, match{id, rankPrefix})
+		case strings.Contains(n, name):
+			matches = append(matches, match{id, rankSubstring})
+		}
+	}
+	if all {
+		for id := range x.names {
+			consider(id)
+		}
+	} else {
+		for id := range candidates {
+			consider(id)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if x.names[a.id] != x.names[b.id] {
+			return x.names[a.id] < x.names[b.id]
+		}
+		return a.id < b.id
+	})
+	ids := make([]int64, len(matches))
+	for i, m := range matches {
+		ids[i] = m.id
+	}
+	return ids
+}
+
+//remove drops id from the index, x.mu must be held
+func (x *searchIndex) remove(id int64) {
+	name, ok := x.names[id]
+	if !ok {
+		return
+	}
+	removePosting(x.byTitle, x.titles[id], id)
+	for _, t := range trigrams(name) {
+		removePosting(x.trigrams, t, id)
+	}
+	delete(x.names, id)
+	delete(x.titles, id)
+}
+
+//trigrams returns the distinct three byte substrings of s
+func trigrams(s string) []string {
+	seen := map[string]bool{}
+	grams := []string{}
+	for i := 0; i+3 <= len(s); i++ {
+		if t := s[i : i+3]; !seen[t] {
+			seen[t] = true
+			grams = append(grams, t)
+		}
+	}
+	return grams
+}
+
+func addPosting(postings map[string]map[int64]bool, key string, id int64) {
+	if postings[key] == nil {
+		postings[key] = map[int64]bool{}
+	}
+	postings[key][id] = true
+}
+
+func removePosting(postings map[string]map[int64]bool, key string, id int64) {
+	delete(postings[key], id)
+	if len(postings[key]) == 0 {
+		delete(postings, key)
+	}
+}
@@ -1,18 +1,21 @@
 package skeleton
 
 import (
+	"context"
 	"fmt"
 	"github.com/nilangshah/hrapp/admin"
 	"github.com/nilangshah/hrapp/grpcserver"
 	"github.com/nilangshah/hrapp/util"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
-	"time"
 )
 
 var (
@@ -31,16 +34,39 @@ type Server struct {
 	stoppedEventChan  chan bool
 	stopped           uint32
 	config            *ServerConfig
+	logLevel          zap.AtomicLevel
+	grpcServer        *grpcserver.Server
+	reload            ReloadFunc
+	stopping          uint32
 }
 type ServerConfig struct {
-	GRPCConfig  *grpcserver.GRPCConfig
-	AdminConfig *admin.AdminConfig
+	GRPCConfig  *grpcserver.GRPCConfig `config:"grpc"`
+	AdminConfig *admin.AdminConfig     `config:"admin"`
+	//LogLevel is the lowest level logged, debug, info, warn or error, info when empty
+	LogLevel string `config:"log-level"`
 }
 
-// Init the global server
-func Init(serviceImpl grpcserver.GRPCImpl, config *ServerConfig) error {
+//Validate checks that the log level is known to zap
+func (c *ServerConfig) Validate() error {
+	_, err := c.level()
+	return err
+}
+
+func (c *ServerConfig) level() (zapcore.Level, error) {
+	var level zapcore.Level
+	if c.LogLevel == "" {
+		return zapcore.InfoLevel, nil
+	}
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return level, errors.Wrapf(err, "log-level %q", c.LogLevel)
+	}
+	return level, nil
+}
+
+// Init the global server, routes are served by the admin server
+func Init(serviceImpl grpcserver.GRPCImpl, config *ServerConfig, routes ...admin.Route) error {
 	var err error
-	if server, err = newServer(serviceImpl, config); err != nil {
+	if server, err = newServer(serviceImpl, config, routes); err != nil {
 		return err
 	}
 	return nil
@@ -54,7 +80,7 @@ func Run() error {
 	return server.run()
 }
 
-func newServer(serviceImpl grpcserver.GRPCImpl, config *ServerConfig) (*Server, error) {
+func newServer(serviceImpl grpcserver.GRPCImpl, config *ServerConfig, routes []admin.Route) (*Server, error) {
 	s := &Server{name: util.SERVICENAME, version: util.SERVICEVERSION,
 		markedForShutdown: false,
 		adminStoppedEvent: make(chan error, 1),
@@ -67,7 +93,7 @@ func newServer(serviceImpl grpcserver.GRPCImpl, config *ServerConfig) (*Server,
 		os.Exit(1)
 	}
 
-	if err := s.initAdmin(); err != nil {
+	if err := s.initAdmin(routes); err != nil {
 		s.Logger.Error("Error occurred initializing admin server", zap.Error(err))
 		os.Exit(1)
 	}
@@ -76,11 +102,40 @@ func newServer(serviceImpl grpcserver.GRPCImpl, config *ServerConfig) (*Server,
 		s.Logger.Error("Error occurred initializing gRPC service", zap.Error(err))
 		os.Exit(1)
 	}
+	s.initChecks()
 	return s, nil
 }
 
+//AddCheck adds a check of the service to the probes of the global server, see admin.AdminServer.AddCheck
+func AddCheck(name string, check admin.CheckFunc, probes ...string) {
+	server.adminServer.AddCheck(name, check, probes...)
+}
+
+//initChecks adds the checks of the servers to the admin probes
+func (s *Server) initChecks() {
+	s.adminServer.AddCheck("grpc_server", s.grpcServer.ServeCheck, admin.LIVEZ)
+	s.adminServer.AddCheck("grpc_listener", s.grpcServer.ListenerCheck, admin.STARTUPZ, admin.READYZ)
+	s.adminServer.AddCheck("tls_certificate", s.grpcServer.CertificateCheck, admin.READYZ)
+	s.adminServer.AddCheck("shutdown", s.shutdownCheck, admin.READYZ)
+}
+
+//shutdownCheck fails once the server is shutting down so that no new requests are routed to it
+func (s *Server) shutdownCheck(ctx context.Context) error {
+	if atomic.LoadUint32(&s.stopping) == 1 {
+		return errors.New("server is shutting down")
+	}
+	return nil
+}
+
 func (s *Server) initLogger() error {
-	logger, err := zap.NewProduction()
+	level, err := s.config.level()
+	if err != nil {
+		return err
+	}
+	s.logLevel = zap.NewAtomicLevelAt(level)
+	loggerConfig := zap.NewProductionConfig()
+	loggerConfig.Level = s.logLevel
+	logger, err := loggerConfig.Build()
 	if err != nil {
 		return errors.Wrap(err, "Error occurred while initializing logger")
 	}
@@ -88,18 +143,20 @@ func (s *Server) initLogger() error {
 	return nil
 }
 
-func (s *Server) initAdmin() error {
+func (s *Server) initAdmin(routes []admin.Route) error {
 	s.Logger.Info("Admin: Initializing Admin framework")
-	s.adminServer = admin.NewServer(s.config.AdminConfig)
+	s.adminServer = admin.NewServer(s.config.AdminConfig, routes...)
 	err := s.adminServer.Init(s.Logger)
 	if err != nil {
 		return errors.Wrap(err, "Error occurred while initializing AdminServer")
 	}
+	prometheus.MustRegister(configReloads, configReloadTime)
 	return nil
 }
 
 func (s *Server) initService(serviceImpl grpcserver.GRPCImpl) error {
-	s.service = grpcserver.NewServer(s.config.GRPCConfig, serviceImpl)
+	s.grpcServer = grpcserver.NewServer(s.config.GRPCConfig, serviceImpl)
+	s.service = s.grpcServer
 	err := s.service.Init(s.Logger)
 	if err != nil {
 		return errors.Wrap(err, "Error occurred while initializing gRPC Server")
@@ -121,10 +178,17 @@ func (s *Server) run() (err error) {
 	}(s.service)
 
 	s.adminServer.Run()
-	time.Sleep(2 * time.Second)
-	s.adminServer.Health = true
-	s.Logger.Info("Server:  Health set to true")
-	s.Logger.Info("Server:  Application started")
+	//healthy once gRPC accepts connections
+	select {
+	case <-s.grpcServer.Listening():
+		s.adminServer.Health = true
+		s.Logger.Info("Server:  Health set to true")
+		s.Logger.Info("Server:  Application started")
+	case err = <-s.grpcServer.Failed():
+		s.Logger.Error("Server:  gRPC server failed to start", zap.Error(err))
+		s.Shutdown()
+		return err
+	}
 
 	osEvent := make(chan os.Signal, 1)
 
@@ -136,29 +200,35 @@ Loop:
 		case sig := <-osEvent:
 			s.Logger.Info("Signal received", zap.Stringer("signal", sig))
 			if sig == syscall.SIGHUP {
-				//TODO reload service
+				s.reloadConfig()
 			} else {
 				break Loop
 			}
 		case <-s.stoppedEventChan:
 			break Loop
 
+		case err = <-s.grpcServer.Failed():
+			s.Logger.Error("Server:  gRPC server stopped serving", zap.Error(err))
+			break Loop
+
 		case err = <-s.adminStoppedEvent:
 			if err != http.ErrServerClosed {
 				s.Logger.Error("Server:  Local administration HTTP server is stopped with error", zap.Error(err))
 				s.adminServer = nil
 				break Loop
 			}
+			err = nil
 		}
 	}
 
 	s.Shutdown()
 
-	return nil
+	return err
 }
 
 // Shutdown the server gracefully
 func (s *Server) Shutdown() error {
+	atomic.StoreUint32(&s.stopping, 1)
 	s.adminServer.Health = false
 	s.service.HandleCommand("SHUTDOWN", nil)
 	s.Logger.Info("Server:  Shutting down the admin, waiting for all the servers and service to shutdown")
@@ -0,0 +1,77 @@
+package skeleton
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	configReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "How many configuration reloads triggered by SIGHUP, partitioned by result (success, failure)",
+		},
+		[]string{"result"},
+	)
+	configReloadTime = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "Unix time of the last successful configuration reload",
+		},
+	)
+)
+
+//ReloadFunc reads the configuration again and checks the settings of the service without applying them.
+//It returns the server settings, checked by the skeleton, and the function applying the service settings,
+//called once every setting passed its checks
+type ReloadFunc func() (*ServerConfig, func(), error)
+
+//OnReload sets the function called on SIGHUP, it must be called after Init
+func OnReload(reload ReloadFunc) {
+	server.reload = reload
+}
+
+//reloadConfig applies the log level and the tls certificates of a newly read configuration, a failed
+//reload keeps the running configuration
+func (s *Server) reloadConfig() {
+	if s.reload == nil {
+		s.Logger.Warn("Server:  No configuration reload registered, ignoring SIGHUP")
+		return
+	}
+	if err := s.applyConfig(); err != nil {
+		configReloads.WithLabelValues("failure").Inc()
+		s.Logger.Error("Server:  Configuration reload failed, keeping the running configuration", zap.Error(err))
+		return
+	}
+	configReloads.WithLabelValues("success").Inc()
+	configReloadTime.SetToCurrentTime()
+	s.Logger.Info("Server:  Configuration reloaded")
+}
+
+//applyConfig checks the service settings, the log level and the tls certificates, which are loaded, before
+//applying any of them so that a failed reload changes nothing
+func (s *Server) applyConfig() error {
+	config, applyService, err := s.reload()
+	if err != nil {
+		return err
+	}
+	level, err := config.level()
+	if err != nil {
+		return err
+	}
+	applyGRPC, err := s.grpcServer.PrepareReload(config.GRPCConfig)
+	if err != nil {
+		return err
+	}
+	applyService()
+	applyGRPC()
+	if config.AdminConfig.ListenAddress != s.config.AdminConfig.ListenAddress {
+		s.Logger.Warn("Server:  Admin listen address changed, restart the service to apply it", zap.String("old", s.config.AdminConfig.ListenAddress), zap.String("new", config.AdminConfig.ListenAddress))
+	}
+	if level != s.logLevel.Level() {
+		s.Logger.Info("Server:  Log level changed", zap.Stringer("old", s.logLevel.Level()), zap.Stringer("new", level))
+		s.logLevel.SetLevel(level)
+	}
+	s.config.LogLevel = config.LogLevel
+	return nil
+}
@@ -0,0 +1,53 @@
+package skeleton
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/nilangshah/hrapp"
+	"github.com/nilangshah/hrapp/admin"
+	"github.com/nilangshah/hrapp/grpcserver"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloadConfig(t *testing.T) {
+	certs := &grpcserver.TlsConfig{TlsEnabled: true, CAPath: "../grpcserver/certs/root-ca.crt", CertPath: "../grpcserver/certs/mydomain.com.crt", KeyPath: "../grpcserver/certs/mydomain.com.key"}
+	impl := hrapp.NewServiceImpl(&hrapp.ServiceImplConfig{Store: "memory", SeedFile: "../resource/hrapp.json", CacheSize: 10, CacheTTL: time.Minute})
+	grpcConfig := &grpcserver.GRPCConfig{ListenAddress: "127.0.0.1:0", TlsConfig: certs}
+	grpcServer := grpcserver.NewServer(grpcConfig, impl)
+	assert.Equal(t, nil, grpcServer.Init(zap.NewNop()))
+	defer impl.ShutDown()
+	s := &Server{
+		Logger:     zap.NewNop(),
+		config:     &ServerConfig{GRPCConfig: grpcConfig, AdminConfig: &admin.AdminConfig{ListenAddress: "127.0.0.1:8080"}},
+		logLevel:   zap.NewAtomicLevelAt(zapcore.InfoLevel),
+		grpcServer: grpcServer,
+	}
+	var reloaded *grpcserver.TlsConfig
+	s.reload = func() (*ServerConfig, func(), error) {
+		apply, err := impl.PrepareReload(&hrapp.ServiceImplConfig{Store: "memory", SeedFile: "../resource/hrapp.json", CacheSize: 10, CacheTTL: 5 * time.Minute})
+		if err != nil {
+			return nil, nil, err
+		}
+		config := &ServerConfig{GRPCConfig: &grpcserver.GRPCConfig{ListenAddress: "127.0.0.1:0", TlsConfig: reloaded}, AdminConfig: s.config.AdminConfig, LogLevel: "debug"}
+		return config, apply, nil
+	}
+
+	//a certificate that fails to load keeps the whole running configuration
+	failures := testutil.ToFloat64(configReloads.WithLabelValues("failure"))
+	reloaded = &grpcserver.TlsConfig{TlsEnabled: true, CAPath: certs.CAPath, CertPath: certs.CertPath, KeyPath: certs.CAPath}
+	s.reloadConfig()
+	assert.Equal(t, failures+1, testutil.ToFloat64(configReloads.WithLabelValues("failure")))
+	assert.Equal(t, time.Minute, impl.Config.CacheTTL)
+	assert.Equal(t, zapcore.InfoLevel, s.logLevel.Level())
+
+	successes := testutil.ToFloat64(configReloads.WithLabelValues("success"))
+	reloaded = certs
+	s.reloadConfig()
+	assert.Equal(t, successes+1, testutil.ToFloat64(configReloads.WithLabelValues("success")))
+	assert.Equal(t, 5*time.Minute, impl.Config.CacheTTL)
+	assert.Equal(t, zapcore.DebugLevel, s.logLevel.Level())
+}
@@ -0,0 +1,98 @@
+package hrapp
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+//StoreFactory creates an EmployeeStore from the service configuration
+type StoreFactory func(logger *zap.Logger, config *ServiceImplConfig) (EmployeeStore, error)
+
+var storeFactories = map[string]StoreFactory{}
+
+//healthChecker is implemented by stores depending on a backend that can fail
+type healthChecker interface {
+	healthCheck() error
+}
+
+//reloader is implemented by stores that apply settings of a reloaded configuration while serving.
+//prepareReload checks config without changing the store, the function it returns applies it and can't fail
+type reloader interface {
+	prepareReload(config *ServiceImplConfig) (func(), error)
+}
+
+func init() {
+	RegisterStore("cassandra", func(logger *zap.Logger, config *ServiceImplConfig) (EmployeeStore, error) {
+		return EmployeeStoreInit(logger, config.DBConfig, config.SearchRefresh)
+	})
+	RegisterStore("memory", func(logger *zap.Logger, config *ServiceImplConfig) (EmployeeStore, error) {
+		return MemoryStoreInit(logger, config.SeedFile)
+	})
+	RegisterStore("file", func(logger *zap.Logger, config *ServiceImplConfig) (EmployeeStore, error) {
+		return FileStoreInit(logger, config.DataDir, config.SeedFile, config.SnapshotEvery)
+	})
+}
+
+//RegisterStore makes an EmployeeStore backend selectable by name through ServiceImplConfig.Store
+func RegisterStore(name string, factory StoreFactory) {
+	if _, dup := storeFactories[name]; dup {
+		panic("hrapp: RegisterStore called twice for store " + name)
+	}
+	storeFactories[name] = factory
+}
+
+//StoreNames returns the registered backend names in sorted order
+func StoreNames() []string {
+	names := make([]string, 0, len(storeFactories))
+	for name := range storeFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//NewEmployeeStore creates the backend named by config.Store, cassandra when none is configured,
+//behind a cache when config.CacheSize is set
+func NewEmployeeStore(logger *zap.Logger, config *ServiceImplConfig) (EmployeeStore, error) {
+	name := config.Store
+	if name == "" {
+		name = "cassandra"
+	}
+	factory, ok := storeFactories[name]
+	if !ok {
+		return nil, errors.Errorf("unknown employee store %q, registered stores are %v", name, StoreNames())
+	}
+	logger.Info("DataAccess: Creating employee store", zap.String("store", name))
+	store, err := factory(logger, config)
+	if err != nil || config.CacheSize <= 0 {
+		return store, err
+	}
+	registerCacheMetrics.Do(func() {
+		prometheus.MustRegister(cacheReqs, cacheEvictions)
+	})
+	return NewCacheStore(logger, store, config.CacheSize, config.CacheTTL), nil
+}
+
+//ForEachEmployee calls fn with every employee matching filter, reading the store a page at a time.
+//It stops at the first error returned by the store or by fn
+func ForEachEmployee(store EmployeeStore, filter *EmployeeFilter, pageSize int, fn func(*Employee) error) error {
+	var pageState []byte
+	for {
+		employees, next, err := store.ListEmployees(filter, pageSize, pageState)
+		if err != nil {
+			return err
+		}
+		for _, emp := range employees {
+			if err := fn(emp); err != nil {
+				return err
+			}
+		}
+		if len(next) == 0 {
+			return nil
+		}
+		pageState = next
+	}
+}
@@ -0,0 +1,8 @@
+package util
+
+import "strings"
+
+//MethodName turns the full gRPC method "/hrapp/getEmployee" into the metric label "getemployee"
+func MethodName(fullMethod string) string {
+	return strings.ToLower(fullMethod[strings.LastIndex(fullMethod, "/")+1:])
+}
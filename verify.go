@@ -0,0 +1,289 @@
+package hrapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap"
+)
+
+//OrgReport describes the inconsistencies of a reporting hierarchy
+type OrgReport struct {
+	//Healthy is set when the hierarchy is a single tree and no problem was found
+	Healthy   bool `json:"healthy"`
+	Employees int  `json:"employees"`
+	//Root is the employee listed in no reports heading the largest tree, 0 if there is none
+	Root int64 `json:"root"`
+	//Roots are all employees listed in no reports, more than one means the hierarchy is split
+	Roots []int64 `json:"roots"`
+	//Orphans are the roots other than Root, their teams can't be reached from the top of the hierarchy
+	Orphans []int64 `json:"orphans"`
+	//Cycles are the loops in the reports links, each listed once starting from its lowest id
+	Cycles            [][]int64         `json:"cycles"`
+	DanglingReports   []DanglingReport  `json:"dangling_reports"`
+	MultipleParents   []MultipleParents `json:"multiple_parents"`
+	ManagerMismatches []ManagerMismatch `json:"manager_mismatches"`
+	//Repaired are the rows rewritten by a repair, as written
+	Repaired []*Employee `json:"repaired,omitempty"`
+}
+
+//DanglingReport is an entry of a reports list naming an employee that doesn't exist
+type DanglingReport struct {
+	ManagerId int64 `json:"manager_id"`
+	ReportId  int64 `json:"report_id"`
+}
+
+//MultipleParents is an employee listed in the reports of more than one manager, or twice by the same one
+type MultipleParents struct {
+	Id         int64   `json:"id"`
+	ManagerIds []int64 `json:"manager_ids"`
+}
+
+//ManagerMismatch is an employee listed in the reports of one manager whose manager_id names another
+type ManagerMismatch struct {
+	Id        int64 `json:"id"`
+	ManagerId int64 `json:"manager_id"`
+	ListedBy  int64 `json:"listed_by"`
+}
+
+//RepairOptions enables the repair of the problems VerifyOrg finds
+type RepairOptions struct {
+	//AdoptOrphansUnder is the manager of orphans whose manager_id doesn't name an existing employee,
+	//0 leaves them alone
+	AdoptOrphansUnder int64
+}
+
+//VerifyOrg loads every employee of store and checks the hierarchy they form. With opts it also repairs what
+//has a single obvious fix and writes the repaired rows back with PutEmployees. Cycles are never repaired.
+//The report describes the hierarchy as it was loaded
+func VerifyOrg(store EmployeeStore, opts *RepairOptions) (*OrgReport, error) {
+	employees := []*Employee{}
+	err := ForEachEmployee(store, &EmployeeFilter{}, MAXPAGESIZE, func(emp *Employee) error {
+		employees = append(employees, emp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	report := CheckOrg(employees)
+	if opts == nil || report.Healthy {
+		return report, nil
+	}
+	repaired := RepairOrg(employees, report, opts)
+	if len(repaired) > 0 {
+		if err := store.PutEmployees(repaired); err != nil {
+			return nil, err
+		}
+	}
+	report.Repaired = repaired
+	return report, nil
+}
+
+//CheckOrg finds the roots, orphans, cycles, dangling reports, employees with several managers and
+//manager_id values disagreeing with the reports lists of employees
+func CheckOrg(employees []*Employee) *OrgReport {
+	byId := make(map[int64]*Employee, len(employees))
+	for _, emp := range employees {
+		byId[emp.Id] = emp
+	}
+	report := &OrgReport{
+		Employees:         len(employees),
+		Roots:             []int64{},
+		Orphans:           []int64{},
+		Cycles:            [][]int64{},
+		DanglingReports:   []DanglingReport{},
+		MultipleParents:   []MultipleParents{},
+		ManagerMismatches: []ManagerMismatch{},
+	}
+	parents := map[int64][]int64{}
+	for _, emp := range sortedEmployees(byId) {
+		for _, reportId := range emp.Reports {
+			if _, ok := byId[reportId]; !ok {
+				report.DanglingReports = append(report.DanglingReports, DanglingReport{ManagerId: emp.Id, ReportId: reportId})
+				continue
+			}
+			parents[reportId] = append(parents[reportId], emp.Id)
+		}
+	}
+	biggest := 0
+	for _, emp := range sortedEmployees(byId) {
+		listedBy := parents[emp.Id]
+		switch {
+		case len(listedBy) == 0:
+			report.Roots = append(report.Roots, emp.Id)
+			if size := len(reachable(byId, emp.Id)); size > biggest {
+				report.Root, biggest = emp.Id, size
+			}
+		case len(listedBy) > 1:
+			report.MultipleParents = append(report.MultipleParents, MultipleParents{Id: emp.Id, ManagerIds: listedBy})
+		case listedBy[0] != emp.ManagerId:
+			report.ManagerMismatches = append(report.ManagerMismatches, ManagerMismatch{Id: emp.Id, ManagerId: emp.ManagerId, ListedBy: listedBy[0]})
+		}
+	}
+	for _, id := range report.Roots {
+		if id != report.Root {
+			report.Orphans = append(report.Orphans, id)
+		}
+	}
+	report.Cycles = findCycles(byId)
+	report.Healthy = len(report.Roots) == 1 && len(report.Cycles) == 0 && len(report.DanglingReports) == 0 &&
+		len(report.MultipleParents) == 0 && len(report.ManagerMismatches) == 0
+	return report
+}
+
+//RepairOrg returns repaired copies of the employees that need a change: dangling reports are dropped,
+//employees with several managers stay only with the one their manager_id names, or else the first one,
+//manager_id is set to the manager listing the employee, and orphans join the reports of their manager_id,
+//or of opts.AdoptOrphansUnder when that doesn't exist, unless that would close a loop
+func RepairOrg(employees []*Employee, report *OrgReport, opts *RepairOptions) []*Employee {
+	byId := make(map[int64]*Employee, len(employees))
+	for _, emp := range employees {
+		byId[emp.Id] = copyEmployee(emp)
+	}
+	changed := map[int64]bool{}
+	for _, dangling := range report.DanglingReports {
+		manager := byId[dangling.ManagerId]
+		manager.Reports = removeId(manager.Reports, dangling.ReportId)
+		changed[manager.Id] = true
+	}
+	for _, multiple := range report.MultipleParents {
+		emp := byId[multiple.Id]
+		keep := multiple.ManagerIds[0]
+		for _, managerId := range multiple.ManagerIds {
+			if managerId == emp.ManagerId {
+				keep = managerId
+			}
+		}
+		for _, managerId := range uniqueIds(multiple.ManagerIds) {
+			manager := byId[managerId]
+			manager.Reports = removeId(manager.Reports, emp.Id)
+			if managerId == keep {
+				manager.Reports = append(manager.Reports, emp.Id)
+			}
+			changed[managerId] = true
+		}
+		if emp.ManagerId != keep {
+			emp.ManagerId = keep
+			changed[emp.Id] = true
+		}
+	}
+	for _, mismatch := range report.ManagerMismatches {
+		byId[mismatch.Id].ManagerId = mismatch.ListedBy
+		changed[mismatch.Id] = true
+	}
+	for _, id := range report.Orphans {
+		emp := byId[id]
+		managerId := emp.ManagerId
+		if _, ok := byId[managerId]; !ok || managerId == id {
+			managerId = opts.AdoptOrphansUnder
+		}
+		manager, ok := byId[managerId]
+		if !ok || reachable(byId, id)[managerId] {
+			continue
+		}
+		manager.Reports = append(manager.Reports, id)
+		emp.ManagerId = managerId
+		changed[managerId], changed[id] = true, true
+	}
+	repaired := []*Employee{}
+	for _, emp := range sortedEmployees(byId) {
+		if changed[emp.Id] {
+			repaired = append(repaired, emp)
+		}
+	}
+	return repaired
+}
+
+//ServeVerify reports the consistency of the hierarchy as JSON, it never changes the store. Repairs are
+//made with the verify -repair command
+func (s *ServiceImpl) ServeVerify(w http.ResponseWriter, r *http.Request) {
+	report, err := VerifyOrg(s.empStore, nil)
+	if err != nil {
+		s.logger.Error("Verify: Failed to verify hierarchy", zap.Error(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.logger.Info("Verify: Hierarchy verified", zap.Bool("healthy", report.Healthy))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+//reachable returns the employees found following reports from id, id included
+func reachable(byId map[int64]*Employee, id int64) map[int64]bool {
+	seen := map[int64]bool{id: true}
+	stack := []int64{id}
+	for len(stack) > 0 {
+		emp := byId[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		for _, reportId := range emp.Reports {
+			if _, ok := byId[reportId]; ok && !seen[reportId] {
+				seen[reportId] = true
+				stack = append(stack, reportId)
+			}
+		}
+	}
+	return seen
+}
+
+//findCycles returns the loops of reports links with a depth first search, a link back to an
+//employee still on the search path closes a loop
+func findCycles(byId map[int64]*Employee) [][]int64 {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := map[int64]int{}
+	cycles := [][]int64{}
+	path := []int64{}
+	var visit func(id int64)
+	visit = func(id int64) {
+		state[id] = onPath
+		path = append(path, id)
+		for _, reportId := range byId[id].Reports {
+			if _, ok := byId[reportId]; !ok {
+				continue
+			}
+			switch state[reportId] {
+			case unvisited:
+				visit(reportId)
+			case onPath:
+				for i := len(path) - 1; i >= 0; i-- {
+					if path[i] == reportId {
+						cycles = append(cycles, rotateToLowest(path[i:]))
+						break
+					}
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+	for _, emp := range sortedEmployees(byId) {
+		if state[emp.Id] == unvisited {
+			visit(emp.Id)
+		}
+	}
+	return cycles
+}
+
+//rotateToLowest returns a copy of the loop starting from its lowest id
+func rotateToLowest(loop []int64) []int64 {
+	lowest := 0
+	for i, id := range loop {
+		if id < loop[lowest] {
+			lowest = i
+		}
+	}
+	return append(append([]int64{}, loop[lowest:]...), loop[:lowest]...)
+}
+
+func sortedEmployees(byId map[int64]*Employee) []*Employee {
+	employees := make([]*Employee, 0, len(byId))
+	for _, emp := range byId {
+		employees = append(employees, emp)
+	}
+	sort.Slice(employees, func(i, j int) bool { return employees[i].Id < employees[j].Id })
+	return employees
+}